@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nodge/multiplexer/internal/config"
+)
+
+// runConfig implements the config subcommand, tools for writing configuration files
+func runConfig(args []string) int {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = configUsage(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	action, rest := fs.Arg(0), fs.Args()[1:]
+	switch action {
+	case "schema":
+		schema, err := config.Schema()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(schema))
+		return 0
+	case "validate":
+		return validateConfig(rest)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown action '%s'\n", action)
+		fs.Usage()
+		return 2
+	}
+}
+
+// validateConfig checks a configuration file the way the multiplexer loads
+// it, and prints every problem found with its position
+func validateConfig(args []string) int {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	var profiles profilesFlag
+	fs.Var(&profiles, "profile", "Validate the commands of the profile and their dependencies (comma-separated or repeated)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Error: validate expects exactly one configuration file\n")
+		return 2
+	}
+	path := fs.Arg(0)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var problems []string
+	add := func(err error) {
+		var validation config.ValidationErrors
+		if !errors.As(err, &validation) {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			return
+		}
+		for _, problem := range validation {
+			problems = append(problems, problem.Error())
+		}
+	}
+
+	// Loading validates the configuration strictly
+	cfg, err := config.Load(path, false, "")
+	if err != nil {
+		add(err)
+	} else if err := cfg.SelectProfiles(profiles); err != nil {
+		add(err)
+	} else if resolved, err := cfg.Resolve(cwd); err != nil {
+		add(err)
+	} else if err := resolved.ValidateAtRuntime(cwd); err != nil {
+		add(err)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s) found\n", path, len(problems))
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: configuration is valid\n", path)
+	return 0
+}
+
+func configUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s config schema > multiplexer.schema.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s config validate [--profile names] <file>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+}
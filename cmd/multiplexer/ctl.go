@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nodge/multiplexer/internal/control"
+)
+
+// runCtl implements the ctl subcommand, a client for the control socket of a running multiplexer
+func runCtl(args []string) int {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	socketPath := fs.String("socket", "", "Path to the control socket (defaults to $"+control.SocketEnv+" or the default socket)")
+	fs.Usage = ctlUsage(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	path := *socketPath
+	if path == "" {
+		path = os.Getenv(control.SocketEnv)
+	}
+	if path == "" {
+		path = control.DefaultSocketPath()
+	}
+
+	action, rest := fs.Arg(0), fs.Args()[1:]
+	actionFlags := flag.NewFlagSet("ctl "+action, flag.ExitOnError)
+	asJSON := actionFlags.Bool("json", false, "Print panes as JSON (list)")
+	enter := actionFlags.Bool("enter", false, "Append a carriage return to the input (send)")
+	scrollback := actionFlags.Bool("scrollback", false, "Include the scrollback (dump)")
+	actionFlags.Parse(rest)
+
+	req := control.Request{Action: action}
+	switch action {
+	case control.ActionList:
+	case control.ActionStart, control.ActionStop, control.ActionRestart, control.ActionDump:
+		if actionFlags.NArg() != 1 {
+			fmt.Fprintf(os.Stderr, "Error: %s expects exactly one pane name\n", action)
+			return 2
+		}
+		req.Pane = actionFlags.Arg(0)
+		req.Scrollback = *scrollback
+	case control.ActionSend:
+		if actionFlags.NArg() < 2 {
+			fmt.Fprintf(os.Stderr, "Error: send expects a pane name and the input\n")
+			return 2
+		}
+		req.Pane = actionFlags.Arg(0)
+		req.Input = strings.Join(actionFlags.Args()[1:], " ")
+		if *enter {
+			req.Input += "\r"
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown action '%s'\n", action)
+		fs.Usage()
+		return 2
+	}
+
+	resp, err := control.Call(path, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch action {
+	case control.ActionList:
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(resp.Panes)
+			return 0
+		}
+		printPanes(resp.Panes)
+	case control.ActionDump:
+		fmt.Println(resp.Output)
+	}
+
+	return 0
+}
+
+// printPanes prints the pane list as a table
+func printPanes(panes []control.PaneInfo) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "NAME\tSTATE\tREADY\tPID\tEXIT\tTITLE")
+	for _, p := range panes {
+		pid := ""
+		if p.PID != 0 {
+			pid = fmt.Sprint(p.PID)
+		}
+		exit := p.Signal
+		if p.ExitCode != nil {
+			exit = fmt.Sprint(*p.ExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Name, p.State, p.Ready, pid, exit, p.Title)
+	}
+}
+
+func ctlUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s ctl [--socket path] list [--json]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s ctl [--socket path] start|stop|restart <pane>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s ctl [--socket path] send [--enter] <pane> <input>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s ctl [--socket path] dump [--scrollback] <pane>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+}
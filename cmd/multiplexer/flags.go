@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/nodge/multiplexer/internal/control"
+	"github.com/nodge/multiplexer/internal/shellwords"
 )
 
 // Implements flag.Value interface for string slice flags
@@ -19,20 +22,49 @@ func (s *stringSliceFlag) Set(value string) error {
 	return nil
 }
 
+// Implements flag.Value interface for profile names, given comma-separated or repeated
+type profilesFlag []string
+
+func (p *profilesFlag) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *profilesFlag) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*p = append(*p, name)
+		}
+	}
+	return nil
+}
+
 type flagConfig struct {
 	commands     stringSliceFlag
 	configPath   string
 	configFormat string
 	fromStdin    bool
+	shell        bool
+	profiles     profilesFlag
+	socketPath   string
+	session      string
+	serve        bool
 }
 
+// Session name used by the attach subcommand when none is given
+const defaultSession = "default"
+
 func parseFlags() *flagConfig {
 	cfg := &flagConfig{}
 
 	flag.Var(&cfg.commands, "cmd", "Command to run in the multiplexer (can be specified multiple times)")
-	flag.StringVar(&cfg.configPath, "config", "", "Path to configuration file (JSON or YAML, format detected by extension)")
+	flag.StringVar(&cfg.configPath, "config", "", "Path to configuration file (JSON, YAML or TOML, format detected by extension)")
+	flag.BoolVar(&cfg.shell, "shell", false, "Run the --cmd commands with $SHELL -c, so pipes, && and variables work")
+	flag.Var(&cfg.profiles, "profile", "Run only the commands of the configuration profile and their dependencies (comma-separated or repeated)")
 	flag.BoolVar(&cfg.fromStdin, "stdin", false, "Read configuration from stdin")
-	flag.StringVar(&cfg.configFormat, "format", "", "Configuration format when reading from stdin (json or yaml, defaults to json)")
+	flag.StringVar(&cfg.configFormat, "format", "", "Configuration format when reading from stdin (json, yaml or toml, defaults to json)")
+	flag.StringVar(&cfg.socketPath, "socket", control.DefaultSocketPath(), "Path to the control socket, empty to disable it")
+	flag.StringVar(&cfg.session, "session", "", "Run as a named session in the background that survives terminal disconnects")
+	flag.BoolVar(&cfg.serve, "serve", false, "Run the session daemon (used internally by --session)")
 	flag.Parse()
 
 	return cfg
@@ -68,9 +100,23 @@ func validateFlags(flags *flagConfig) error {
 		}
 	}
 
+	for _, command := range flags.commands {
+		if _, err := shellwords.Split(command); err != nil {
+			return fmt.Errorf("invalid command '%s': %v", command, err)
+		}
+	}
+
+	if len(flags.profiles) > 0 && hasCommands {
+		return fmt.Errorf("--profile can only be used with --config or --stdin")
+	}
+
+	if flags.shell && !hasCommands {
+		return fmt.Errorf("--shell can only be used with --cmd, use 'shell: true' in the configuration file")
+	}
+
 	if flags.configFormat != "" {
-		if flags.configFormat != "json" && flags.configFormat != "yaml" {
-			return fmt.Errorf("config format must be 'json' or 'yaml', got: %s", flags.configFormat)
+		if flags.configFormat != "json" && flags.configFormat != "yaml" && flags.configFormat != "toml" {
+			return fmt.Errorf("config format must be 'json', 'yaml' or 'toml', got: %s", flags.configFormat)
 		}
 
 		if !hasStdin {
@@ -78,5 +124,13 @@ func validateFlags(flags *flagConfig) error {
 		}
 	}
 
+	if flags.session != "" && hasStdin {
+		return fmt.Errorf("cannot read configuration from stdin when running as a session")
+	}
+
+	if flags.serve && flags.session == "" {
+		return fmt.Errorf("--serve requires --session")
+	}
+
 	return nil
 }
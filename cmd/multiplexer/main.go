@@ -5,15 +5,29 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strings"
+	"regexp"
 	"syscall"
 
+	"github.com/nodge/multiplexer/internal/clipboard"
 	"github.com/nodge/multiplexer/internal/config"
+	"github.com/nodge/multiplexer/internal/logfile"
 	"github.com/nodge/multiplexer/internal/multiplexer"
 	"github.com/nodge/multiplexer/internal/process"
+	"github.com/nodge/multiplexer/internal/session"
+	"github.com/nodge/multiplexer/internal/shellwords"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(runCtl(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "attach" {
+		os.Exit(runAttach(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:]))
+	}
+
 	flags := parseFlags()
 
 	if err := validateFlags(flags); err != nil {
@@ -29,7 +43,25 @@ func main() {
 		os.Exit(1)
 	}
 
-	m, err := multiplexer.New(ctx)
+	if flags.session != "" && !flags.serve {
+		// Report configuration errors here, the daemon has no terminal to show them
+		if flags.configPath != "" {
+			if _, err := loadConfiguration(flags.configPath, false, "", flags.profiles, cwd); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(startSession(flags.session))
+	}
+
+	var m *multiplexer.Multiplexer
+	var tty *session.Tty
+	if flags.serve {
+		tty = session.NewTty()
+		m, err = multiplexer.NewWithTty(ctx, tty)
+	} else {
+		m, err = multiplexer.New(ctx)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error creating multiplexer: %v", err)
 		os.Exit(1)
@@ -37,16 +69,44 @@ func main() {
 
 	defer process.Cleanup()
 
+	if tty != nil {
+		server, err := session.Listen(session.SocketPath(flags.session), tty, m.Reengage)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error starting session: %v", err)
+			os.Exit(1)
+		}
+		// Stop accepting clients before the processes are cleaned up
+		defer server.Close()
+		m.EnableDetach(tty.Detach)
+	}
+
+	if flags.socketPath != "" {
+		if err := m.Listen(flags.socketPath); err != nil {
+			// The screen is in use, report the problem once the multiplexer exits
+			defer fmt.Fprintf(os.Stderr, "control socket disabled: %v\n", err)
+		}
+	}
+
 	if flags.configPath != "" || flags.fromStdin {
-		cfg, err := loadConfiguration(flags.configPath, flags.fromStdin, flags.configFormat, cwd)
+		cfg, err := loadConfiguration(flags.configPath, flags.fromStdin, flags.configFormat, flags.profiles, cwd)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			os.Exit(1)
 		}
 
+		if cfg.Keybindings != nil {
+			m.SetKeybindings(keybindings(cfg.Keybindings))
+		}
+		if cfg.Clipboard != nil {
+			m.SetClipboard(clipboardOptions(cfg.Clipboard, cwd))
+		}
 		addProcessesFromConfig(m, cfg, cwd)
+
+		if flags.configPath != "" {
+			go watchConfiguration(ctx, m, cfg.Files(), flags.profiles, cwd)
+		}
 	} else if len(flags.commands) > 0 {
-		addProcessesFromFlags(m, flags.commands, cwd)
+		addProcessesFromFlags(m, flags.commands, flags.shell, cwd)
 	}
 
 	m.Start()
@@ -65,53 +125,193 @@ func setupContext() (context.Context, context.CancelFunc) {
 	return ctx, cancel
 }
 
-func loadConfiguration(configPath string, fromStdin bool, configFormat string, cwd string) (*config.Config, error) {
+func loadConfiguration(configPath string, fromStdin bool, configFormat string, profiles []string, cwd string) (*config.Config, error) {
 	cfg, err := config.Load(configPath, fromStdin, configFormat)
 	if err != nil {
 		return nil, fmt.Errorf("error loading configuration: %v", err)
 	}
 
-	if err := cfg.ValidateAtRuntime(cwd); err != nil {
+	return resolveConfiguration(cfg, profiles, cwd)
+}
+
+// resolveConfiguration selects the commands of the profiles, applies the env
+// files and variables to the loaded configuration and validates the result
+// against the file system
+func resolveConfiguration(cfg *config.Config, profiles []string, cwd string) (*config.Config, error) {
+	if err := cfg.SelectProfiles(profiles); err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+
+	resolved, err := cfg.Resolve(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+
+	if err := resolved.ValidateAtRuntime(cwd); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %v", err)
 	}
 
-	return cfg, nil
+	return resolved, nil
 }
 
 func addProcessesFromConfig(m *multiplexer.Multiplexer, cfg *config.Config, cwd string) {
+	for _, proc := range processesFromConfig(cfg, cwd) {
+		m.AddProcess(proc)
+	}
+}
+
+// processesFromConfig converts the configured commands to processes of the multiplexer
+func processesFromConfig(cfg *config.Config, cwd string) []multiplexer.EventProcess {
+	processes := make([]multiplexer.EventProcess, 0, len(cfg.Commands))
 	for _, cmd := range cfg.Commands {
-		m.AddProcess(
-			cmd.Name,
-			cmd.Command,
-			cmd.Env,
-			cmd.GetTitle(),
-			cmd.GetCWD(cwd),
-			cmd.IsKillable(),
-			cmd.IsAutostart(),
-		)
+		processes = append(processes, multiplexer.EventProcess{
+			Key:        cmd.Name,
+			Cmd:        cmd.GetArgs(),
+			Env:        cmd.Env,
+			Title:      cmd.GetTitle(),
+			Cwd:        cmd.GetCWD(cwd),
+			Killable:   cmd.IsKillable(),
+			Autostart:  cmd.IsAutostart(),
+			DependsOn:  cmd.DependsOn,
+			Ready:      readyProbe(cmd.Ready),
+			Restart:    restartPolicy(cmd.Restart),
+			Log:        logFile(cmd.Log, cmd.GetCWD(cwd)),
+			Scrollback: scrollback(cmd.Scrollback),
+			Stop:       stopOptions(&cmd),
+		})
 	}
+	return processes
 }
 
-func addProcessesFromFlags(m *multiplexer.Multiplexer, commands []string, cwd string) {
+// keybindings converts the configured key chords to their canonical form,
+// the configuration is validated so the chords are known to be valid
+func keybindings(keys *config.Keybindings) multiplexer.Keybindings {
+	normalize := func(bindings map[string]string) map[string]string {
+		result := make(map[string]string, len(bindings))
+		for chord, action := range bindings {
+			if normalized, err := config.NormalizeChord(chord); err == nil {
+				result[normalized] = action
+			}
+		}
+		return result
+	}
+
+	prefix, _ := config.NormalizeChord(keys.Prefix)
+	return multiplexer.Keybindings{
+		Prefix:   prefix,
+		Sidebar:  normalize(keys.Sidebar),
+		Focused:  normalize(keys.Focused),
+		Prefixed: normalize(keys.Prefixed),
+	}
+}
+
+// clipboardOptions converts the configured clipboard, the multiplexer sets the
+// terminal receiving the OSC 52 sequences
+func clipboardOptions(c *config.Clipboard, cwd string) clipboard.Options {
+	return clipboard.Options{
+		Backend:     c.GetBackend(),
+		Path:        c.GetPath(cwd),
+		Command:     c.Command,
+		MaxSize:     int(c.GetMaxSize()),
+		Passthrough: c.Passthrough,
+	}
+}
+
+func restartPolicy(restart *config.RestartPolicy) *multiplexer.RestartPolicy {
+	if restart == nil {
+		return nil
+	}
+
+	return &multiplexer.RestartPolicy{
+		Policy:     restart.GetPolicy(),
+		MaxRetries: restart.MaxRetries,
+		Backoff:    restart.GetBackoff(),
+		MaxBackoff: restart.GetMaxBackoff(),
+		ResetAfter: restart.GetResetAfter(),
+	}
+}
+
+func scrollback(scrollback *config.Scrollback) *multiplexer.Scrollback {
+	if scrollback == nil {
+		return nil
+	}
+
+	return &multiplexer.Scrollback{
+		Lines: scrollback.GetLines(),
+		Spill: scrollback.Spill,
+	}
+}
+
+func logFile(log *config.LogFile, cwd string) *logfile.Options {
+	if log == nil {
+		return nil
+	}
+
+	return &logfile.Options{
+		Path:       log.GetPath(cwd),
+		Plain:      log.GetFormat() == config.LogFormatPlain,
+		Timestamps: log.Timestamps,
+		MaxSize:    log.GetMaxSize(),
+		MaxAge:     log.GetMaxAge(),
+		MaxFiles:   log.GetMaxFiles(),
+	}
+}
+
+func stopOptions(cmd *config.Command) *process.StopOptions {
+	return &process.StopOptions{
+		Signal:  cmd.GetStopSignal(),
+		Timeout: cmd.GetStopTimeout(),
+		Command: cmd.GetStopArgs(),
+	}
+}
+
+func readyProbe(ready *config.ReadyProbe) *multiplexer.ReadyProbe {
+	if ready == nil {
+		return nil
+	}
+
+	probe := &multiplexer.ReadyProbe{
+		TCP:      ready.TCP,
+		HTTP:     ready.HTTP,
+		Status:   ready.Status,
+		Exec:     ready.Exec,
+		Interval: ready.GetInterval(),
+		Timeout:  ready.GetTimeout(),
+	}
+	if ready.Log != "" {
+		// The pattern is checked when the configuration is validated
+		probe.Log = regexp.MustCompile(ready.Log)
+	}
+
+	return probe
+}
+
+// addProcessesFromFlags adds the --cmd commands, split into arguments with
+// shell quoting rules or run by the shell in shell mode. The commands are
+// checked by validateFlags.
+func addProcessesFromFlags(m *multiplexer.Multiplexer, commands []string, shell bool, cwd string) {
 	for i, command := range commands {
-		cmd := strings.Fields(command)
+		cmd, _ := shellwords.Split(command)
 		if len(cmd) == 0 {
 			continue
 		}
+		if shell {
+			cmd = process.Shell(command)
+		}
 
 		name := fmt.Sprintf("cmd%d", i+1)
 		title := "→ " + name
 		env := make(map[string]string)
 
-		m.AddProcess(
-			name,
-			cmd,
-			env,
-			title,
-			cwd,
-			true,
-			true,
-		)
+		m.AddProcess(multiplexer.EventProcess{
+			Key:       name,
+			Cmd:       cmd,
+			Env:       env,
+			Title:     title,
+			Cwd:       cwd,
+			Killable:  true,
+			Autostart: true,
+		})
 	}
 }
 
@@ -121,7 +321,13 @@ func showUsage(errMsg string) {
 	}
 	fmt.Fprintf(os.Stderr, "Usage:\n")
 	fmt.Fprintf(os.Stderr, "  %s --config config.yaml\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s --config config.yaml --profile backend\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "  %s --stdin --format yaml < config.yaml\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "  %s --cmd \"go run main.go\" --cmd \"npm start\"\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s --shell --cmd \"make build && ./server | tee server.log\"\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s --session dev --config config.yaml\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s attach dev\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s ctl list\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s config validate config.yaml\n", os.Args[0])
 	os.Exit(1)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"github.com/nodge/multiplexer/internal/clipboard"
+	"github.com/nodge/multiplexer/internal/config"
+	"github.com/nodge/multiplexer/internal/multiplexer"
+)
+
+// Delay between checks of the configuration files
+const watchInterval = time.Second
+
+// Last seen state of a watched configuration file
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// Returns the state of the file, empty if it cannot be read
+func statFile(path string) watchedFile {
+	info, err := os.Stat(path)
+	if err != nil {
+		return watchedFile{}
+	}
+	data, _ := os.ReadFile(path)
+	return watchedFile{modTime: info.ModTime(), size: info.Size(), data: data}
+}
+
+// Returns true if the contents of the file differ from the state, updating it
+func (w *watchedFile) changed(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || (info.ModTime().Equal(w.modTime) && info.Size() == w.size) {
+		return false // missing while the editor replaces it, or unchanged
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.Equal(data, w.data) {
+		return false
+	}
+	w.data = data
+	return true
+}
+
+// watchConfiguration reloads the configuration into the multiplexer whenever
+// the contents of one of its files change, until the context is cancelled.
+// The files are polled so that editors replacing them on save are handled as
+// well. Files included by a reloaded configuration are watched from then on.
+func watchConfiguration(ctx context.Context, m *multiplexer.Multiplexer, files []string, profiles []string, cwd string) {
+	configPath := files[0]
+	watched := map[string]*watchedFile{}
+	watch := func(files []string) {
+		previous := watched
+		watched = map[string]*watchedFile{}
+		for _, path := range files {
+			if w, ok := previous[path]; ok {
+				watched[path] = w
+				continue
+			}
+			w := statFile(path)
+			watched[path] = &w
+		}
+	}
+	watch(files)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed := false
+		for path, w := range watched {
+			if w.changed(path) {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		if files := reloadConfiguration(m, configPath, profiles, cwd); files != nil {
+			watch(files)
+		}
+	}
+}
+
+// reloadConfiguration loads and validates the configuration file, then replaces the processes, key bindings and clipboard of the multiplexer.
+// Errors are shown in the multiplexer, which keeps the previous configuration.
+// Returns the files of the configuration, nil if it was rejected.
+func reloadConfiguration(m *multiplexer.Multiplexer, configPath string, profiles []string, cwd string) []string {
+	cfg, err := config.Load(configPath, false, "")
+	if err == nil {
+		cfg, err = resolveConfiguration(cfg, profiles, cwd)
+	}
+	if err != nil {
+		m.ReloadFailed(err)
+		return nil
+	}
+
+	// Settings removed from the file fall back to their defaults
+	m.SetKeybindings(multiplexer.Keybindings{})
+	if cfg.Keybindings != nil {
+		m.SetKeybindings(keybindings(cfg.Keybindings))
+	}
+	m.SetClipboard(clipboard.Options{Backend: clipboard.Auto})
+	if cfg.Clipboard != nil {
+		m.SetClipboard(clipboardOptions(cfg.Clipboard, cwd))
+	}
+	m.Reload(processesFromConfig(cfg, cwd))
+
+	return cfg.Files()
+}
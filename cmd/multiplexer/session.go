@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/nodge/multiplexer/internal/process"
+	"github.com/nodge/multiplexer/internal/session"
+)
+
+// startSession attaches to the named session, starting its daemon first if it is not running
+func startSession(name string) int {
+	path := session.SocketPath(name)
+
+	if !session.Alive(path) {
+		if err := spawnSession(path); err != nil {
+			fmt.Fprintf(os.Stderr, "error starting session '%s': %v\n", name, err)
+			return 1
+		}
+	}
+
+	return attachSession(name, path)
+}
+
+// spawnSession starts the session daemon with the same flags and waits until it listens
+func spawnSession(path string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// Not tracked by the process package, the daemon must outlive this process
+	cmd := exec.Command(exe, append(os.Args[1:], "--serve")...)
+	process.Daemonize(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	cmd.Process.Release()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if session.Alive(path) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return fmt.Errorf("session daemon did not start listening on '%s'", path)
+}
+
+// attachSession attaches the terminal to the session and reports why it returned
+func attachSession(name string, path string) int {
+	reason, err := session.Attach(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error attaching to session '%s': %v\n", name, err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "[%s from session '%s']\n", reason, name)
+	return 0
+}
+
+// runAttach implements the attach subcommand
+func runAttach(args []string) int {
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s attach [session]\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	name := defaultSession
+	if fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+
+	path := session.SocketPath(name)
+	if !session.Alive(path) {
+		fmt.Fprintf(os.Stderr, "Error: no session named '%s' is running\n", name)
+		return 1
+	}
+
+	return attachSession(name, path)
+}
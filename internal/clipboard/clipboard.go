@@ -0,0 +1,166 @@
+// Package clipboard copies text to the clipboard of the user.
+//
+// A Clipboard is one of several backends: OSC 52 escape sequences written to
+// the terminal, which also work over SSH, the pbcopy, wl-copy, xclip and xsel
+// tools, a file or named pipe, or any command reading the text on its
+// standard input. New picks the backend from the environment unless one is
+// configured.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nodge/multiplexer/internal/process"
+)
+
+// Backends
+const (
+	Auto    = "auto"
+	OSC52   = "osc52"
+	Pbcopy  = "pbcopy"
+	WlCopy  = "wl-copy"
+	Xclip   = "xclip"
+	Xsel    = "xsel"
+	File    = "file"
+	Command = "command"
+)
+
+// Backends lists the valid backend names
+var Backends = []string{Auto, OSC52, Pbcopy, WlCopy, Xclip, Xsel, File, Command}
+
+// Time after which a command or a named pipe without reader fails
+const timeout = 5 * time.Second
+
+// Clipboard receives copied text
+type Clipboard interface {
+	Copy(text string) error
+}
+
+// Options describes the clipboard backend
+type Options struct {
+	Backend     string    // one of Backends, empty means Auto
+	Path        string    // file or named pipe of the File backend
+	Command     []string  // command and arguments of the Command backend
+	MaxSize     int       // largest text in bytes sent with OSC 52, zero means DefaultMaxSize
+	Passthrough string    // wraps OSC 52 for the terminal multiplexer it runs in, PassthroughTmux or PassthroughScreen
+	Terminal    io.Writer // receives the OSC 52 sequences
+}
+
+// New returns the clipboard of the backend, or of the backend detected from
+// the environment
+func New(opts Options) (Clipboard, error) {
+	backend := opts.Backend
+	if backend == "" || backend == Auto {
+		backend = detect(os.Getenv, exec.LookPath)
+	}
+
+	switch backend {
+	case OSC52:
+		if opts.Terminal == nil {
+			return nil, errors.New("osc52: no terminal to write to")
+		}
+		switch opts.Passthrough {
+		case "", PassthroughTmux, PassthroughScreen:
+		default:
+			return nil, fmt.Errorf("osc52: unknown passthrough '%s'", opts.Passthrough)
+		}
+		return &osc52{w: opts.Terminal, maxSize: opts.MaxSize, passthrough: opts.Passthrough}, nil
+	case Pbcopy:
+		return &command{args: []string{"pbcopy"}}, nil
+	case WlCopy:
+		return &command{args: []string{"wl-copy"}}, nil
+	case Xclip:
+		return &command{args: []string{"xclip", "-selection", "clipboard"}}, nil
+	case Xsel:
+		return &command{args: []string{"xsel", "--clipboard", "--input"}}, nil
+	case File:
+		if opts.Path == "" {
+			return nil, errors.New("file: path cannot be empty")
+		}
+		return &file{path: opts.Path}, nil
+	case Command:
+		if len(opts.Command) == 0 || opts.Command[0] == "" {
+			return nil, errors.New("command: command cannot be empty")
+		}
+		return &command{args: opts.Command}, nil
+	}
+	return nil, fmt.Errorf("unknown backend '%s', must be one of %s", backend, strings.Join(Backends, ", "))
+}
+
+// detect returns the backend that suits the environment: pbcopy in Apple
+// Terminal, which ignores OSC 52, OSC 52 over SSH so the text reaches the
+// local machine, the Wayland or X11 tools of a desktop session and OSC 52
+// otherwise
+func detect(getenv func(string) string, lookPath func(string) (string, error)) string {
+	installed := func(name string) bool {
+		_, err := lookPath(name)
+		return err == nil
+	}
+
+	switch {
+	case getenv("TERM_PROGRAM") == "Apple_Terminal" && installed("pbcopy"):
+		return Pbcopy
+	case getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != "":
+		return OSC52
+	case getenv("WAYLAND_DISPLAY") != "" && installed("wl-copy"):
+		return WlCopy
+	case getenv("DISPLAY") != "" && installed("xclip"):
+		return Xclip
+	case getenv("DISPLAY") != "" && installed("xsel"):
+		return Xsel
+	}
+	return OSC52
+}
+
+// command pipes the text to the standard input of a command
+type command struct {
+	args []string
+}
+
+func (c *command) Copy(text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The output is not captured: xclip and xsel fork a process that keeps
+	// serving the selection with the inherited output open
+	cmd := process.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", c.args[0], err)
+	}
+	return nil
+}
+
+// file replaces the content of a file with the text, or writes it to a named
+// pipe
+type file struct {
+	path string
+}
+
+func (f *file) Copy(text string) error {
+	info, err := os.Stat(f.path)
+	if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		return os.WriteFile(f.path, []byte(text), 0o600)
+	}
+
+	// Opening a pipe without reader would block, it fails instead
+	pipe, err := os.OpenFile(f.path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return fmt.Errorf("named pipe %s: %w", f.path, err)
+	}
+	defer pipe.Close()
+
+	pipe.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err := io.WriteString(pipe, text); err != nil {
+		return fmt.Errorf("named pipe %s: %w", f.path, err)
+	}
+	return nil
+}
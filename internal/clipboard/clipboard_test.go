@@ -0,0 +1,222 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		installed []string
+		want      string
+	}{
+		{
+			name:      "apple terminal",
+			env:       map[string]string{"TERM_PROGRAM": "Apple_Terminal"},
+			installed: []string{"pbcopy"},
+			want:      Pbcopy,
+		},
+		{
+			name:      "ssh session",
+			env:       map[string]string{"SSH_TTY": "/dev/pts/1", "DISPLAY": ":0"},
+			installed: []string{"xclip"},
+			want:      OSC52,
+		},
+		{
+			name:      "wayland",
+			env:       map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"},
+			installed: []string{"wl-copy", "xclip"},
+			want:      WlCopy,
+		},
+		{
+			name:      "x11 with xsel only",
+			env:       map[string]string{"DISPLAY": ":0"},
+			installed: []string{"xsel"},
+			want:      Xsel,
+		},
+		{
+			name:      "x11 without tools",
+			env:       map[string]string{"DISPLAY": ":0"},
+			installed: nil,
+			want:      OSC52,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			lookPath := func(name string) (string, error) {
+				for _, installed := range tt.installed {
+					if name == installed {
+						return "/usr/bin/" + name, nil
+					}
+				}
+				return "", errors.New("not found")
+			}
+			if got := detect(getenv, lookPath); got != tt.want {
+				t.Errorf("detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	tests := []struct {
+		name        string
+		passthrough string
+		want        string
+	}{
+		{
+			name: "plain",
+			want: "\x1b]52;c;aGVsbG8=\x07",
+		},
+		{
+			name:        "tmux",
+			passthrough: PassthroughTmux,
+			want:        "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c, err := New(Options{Backend: OSC52, Terminal: &out, Passthrough: tt.passthrough})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := c.Copy("hello"); err != nil {
+				t.Fatalf("Copy() error = %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Copy() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOSC52_Screen(t *testing.T) {
+	text := strings.Repeat("x", 200)
+	got := wrapOSC52(text, PassthroughScreen)
+
+	chunks := strings.Split(strings.TrimSuffix(got, "\x1b\\"), "\x1b\\")
+	joined := ""
+	for _, chunk := range chunks {
+		if !strings.HasPrefix(chunk, "\x1bP") || len(chunk) > screenChunkSize+2 {
+			t.Fatalf("chunk %q is not a short DCS string", chunk)
+		}
+		joined += strings.TrimPrefix(chunk, "\x1bP")
+	}
+	if want := wrapOSC52(text, ""); joined != want {
+		t.Errorf("chunks = %q, want %q", joined, want)
+	}
+}
+
+func TestOSC52_MaxSize(t *testing.T) {
+	var out bytes.Buffer
+	c, _ := New(Options{Backend: OSC52, Terminal: &out, MaxSize: 4})
+	if err := c.Copy("hello"); err == nil || !strings.Contains(err.Error(), "exceeds the limit of 4 bytes") {
+		t.Errorf("Copy() error = %v, want size error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Copy() wrote %q, want nothing", out.String())
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clipboard")
+	c, err := New(Options{Backend: File, Path: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, text := range []string{"first copy", "second"} {
+		if err := c.Copy(text); err != nil {
+			t.Fatalf("Copy() error = %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("file = %q, want %q", data, "second")
+	}
+}
+
+func TestFile_NamedPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are not files on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "clipboard")
+	if err := exec.Command("mkfifo", path).Run(); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	c, _ := New(Options{Backend: File, Path: path})
+
+	// Without reader the copy fails instead of blocking
+	if err := c.Copy("lost"); err == nil {
+		t.Error("Copy() without reader expected error")
+	}
+
+	reader, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer reader.Close()
+
+	if err := c.Copy("piped"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	buf := make([]byte, 16)
+	n, _ := reader.Read(buf)
+	if got := string(buf[:n]); got != "piped" {
+		t.Errorf("pipe = %q, want %q", got, "piped")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "out")
+	c, err := New(Options{Backend: Command, Command: []string{"sh", "-c", `cat > "$0"`, path}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := c.Copy("from stdin"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "from stdin" {
+		t.Errorf("output = %q, want %q", data, "from stdin")
+	}
+
+	failing, _ := New(Options{Backend: Command, Command: []string{"false"}})
+	if err := failing.Copy("text"); err == nil || !strings.HasPrefix(err.Error(), "false:") {
+		t.Errorf("Copy() error = %v, want command error", err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Backend: "clipboard.exe"},
+		{Backend: File},
+		{Backend: Command},
+		{Backend: OSC52},
+		{Backend: OSC52, Terminal: &bytes.Buffer{}, Passthrough: "zellij"},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) expected error", opts)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultMaxSize is the largest text sent with OSC 52 by default, its base64
+// encoding stays below the 100000 bytes many terminals accept
+const DefaultMaxSize = 74994
+
+// Terminal multiplexers that forward wrapped sequences to the outer terminal
+const (
+	PassthroughTmux   = "tmux"
+	PassthroughScreen = "screen"
+)
+
+// GNU screen drops DCS strings longer than 768 bytes, the sequence is split
+// into chunks of this size
+const screenChunkSize = 76
+
+// osc52 asks the terminal emulator to set its clipboard with an OSC 52 escape
+// sequence
+type osc52 struct {
+	w           io.Writer
+	maxSize     int
+	passthrough string
+}
+
+func (o *osc52) Copy(text string) error {
+	maxSize := o.maxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if len(text) > maxSize {
+		return fmt.Errorf("osc52: text of %d bytes exceeds the limit of %d bytes", len(text), maxSize)
+	}
+
+	_, err := io.WriteString(o.w, wrapOSC52(text, o.passthrough))
+	return err
+}
+
+// wrapOSC52 returns the OSC 52 sequence setting the clipboard to the text,
+// wrapped for the passthrough
+func wrapOSC52(text string, passthrough string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+
+	switch passthrough {
+	case PassthroughTmux:
+		// tmux passes the content of its DCS string on with the escape
+		// characters doubled, when allow-passthrough is on
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case PassthroughScreen:
+		var b strings.Builder
+		for len(seq) > 0 {
+			n := min(len(seq), screenChunkSize)
+			b.WriteString("\x1bP" + seq[:n] + "\x1b\\")
+			seq = seq[n:]
+		}
+		return b.String()
+	}
+	return seq
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Clipboard backends
+const (
+	ClipboardAuto    = "auto"    // detected from the environment
+	ClipboardOSC52   = "osc52"   // escape sequence asking the terminal to set its clipboard, works over SSH
+	ClipboardPbcopy  = "pbcopy"  // macOS
+	ClipboardWlCopy  = "wl-copy" // Wayland
+	ClipboardXclip   = "xclip"   // X11
+	ClipboardXsel    = "xsel"    // X11
+	ClipboardFile    = "file"    // file or named pipe
+	ClipboardCommand = "command" // any command reading the text on its standard input
+)
+
+// clipboardBackends lists the valid clipboard backends
+var clipboardBackends = []string{
+	ClipboardAuto, ClipboardOSC52, ClipboardPbcopy, ClipboardWlCopy, ClipboardXclip,
+	ClipboardXsel, ClipboardFile, ClipboardCommand,
+}
+
+// OSC 52 passthrough modes
+const (
+	PassthroughTmux   = "tmux"
+	PassthroughScreen = "screen"
+)
+
+// Clipboard represents where copied text goes
+type Clipboard struct {
+	Backend     string   `json:"backend,omitempty" yaml:"backend,omitempty"`         // One of `auto`, `osc52`, `pbcopy`, `wl-copy`, `xclip`, `xsel`, `file` or `command` (default: `auto`)
+	Path        string   `json:"path,omitempty" yaml:"path,omitempty"`               // File or named pipe receiving the text with the `file` backend
+	Command     []string `json:"command,omitempty" yaml:"command,omitempty"`         // Command and arguments receiving the text on standard input with the `command` backend
+	MaxSize     string   `json:"max_size,omitempty" yaml:"max_size,omitempty"`       // Largest text copied with `osc52`, e.g. `64KB` (default: about `73KB`)
+	Passthrough string   `json:"passthrough,omitempty" yaml:"passthrough,omitempty"` // Wraps the `osc52` sequence for `tmux` or `screen` (default: none)
+}
+
+// GetBackend returns the clipboard backend
+func (c *Clipboard) GetBackend() string {
+	if c.Backend != "" {
+		return c.Backend
+	}
+	return ClipboardAuto
+}
+
+// GetPath returns the path of the file backend resolved against the working directory
+func (c *Clipboard) GetPath(cwd string) string {
+	if c.Path == "" || filepath.IsAbs(c.Path) {
+		return c.Path
+	}
+	return filepath.Join(cwd, c.Path)
+}
+
+// GetMaxSize returns the largest text in bytes copied with OSC 52, zero means the default
+func (c *Clipboard) GetMaxSize() int64 {
+	if size, err := parseSize(c.MaxSize); err == nil {
+		return size
+	}
+	return 0
+}
+
+// Validate checks the correctness of the clipboard configuration
+func (c *Clipboard) Validate() error {
+	if errors := validateClipboard(c); len(errors) > 0 {
+		return errors[0]
+	}
+	return nil
+}
+
+// validateClipboard checks the backend and the settings it needs
+func validateClipboard(c *Clipboard) ValidationErrors {
+	var errors ValidationErrors
+
+	if !slices.Contains(clipboardBackends, c.GetBackend()) {
+		errors = append(errors, ValidationError{
+			Field:   "clipboard.backend",
+			Message: "must be one of " + strings.Join(clipboardBackends, ", "),
+			Value:   c.Backend,
+		})
+	}
+
+	if c.GetBackend() == ClipboardFile && c.Path == "" {
+		errors = append(errors, ValidationError{
+			Field:   "clipboard.path",
+			Message: "cannot be empty with the file backend",
+		})
+	}
+
+	if c.GetBackend() == ClipboardCommand && (len(c.Command) == 0 || c.Command[0] == "") {
+		errors = append(errors, ValidationError{
+			Field:   "clipboard.command",
+			Message: "cannot be empty with the command backend",
+		})
+	}
+
+	if _, err := parseSize(c.MaxSize); err != nil {
+		errors = append(errors, ValidationError{
+			Field:   "clipboard.max_size",
+			Message: "must be a non-negative size such as 64KB",
+			Value:   c.MaxSize,
+		})
+	}
+
+	switch c.Passthrough {
+	case "", PassthroughTmux, PassthroughScreen:
+	default:
+		errors = append(errors, ValidationError{
+			Field:   "clipboard.passthrough",
+			Message: fmt.Sprintf("must be %s or %s", PassthroughTmux, PassthroughScreen),
+			Value:   c.Passthrough,
+		})
+	}
+
+	return errors
+}
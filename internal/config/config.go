@@ -3,11 +3,32 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nodge/multiplexer/internal/process"
 )
 
 // Config represents the main configuration file structure
 type Config struct {
-	Commands []Command `json:"commands" yaml:"commands"`
+	Commands    []Command    `json:"commands" yaml:"commands"`                           // Commands to run, each one in its own pane
+	Keybindings *Keybindings `json:"keybindings,omitempty" yaml:"keybindings,omitempty"` // Key bindings merged with the default ones
+	Clipboard   *Clipboard   `json:"clipboard,omitempty" yaml:"clipboard,omitempty"`     // Where copied text goes (default: detected from the environment)
+
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"`           // Environment variables shared by all commands
+	EnvFile string            `json:"env_file,omitempty" yaml:"env_file,omitempty"` // Dotenv file with variables shared by all commands
+
+	Include   []string            `json:"include,omitempty" yaml:"include,omitempty"`     // Configuration files merged into this one, relative to its directory
+	Profiles  map[string][]string `json:"profiles,omitempty" yaml:"profiles,omitempty"`   // Named subsets of the commands, selected with `--profile`
+	Defaults  *Command            `json:"defaults,omitempty" yaml:"defaults,omitempty"`   // Fields inherited by all commands
+	Templates map[string]Command  `json:"templates,omitempty" yaml:"templates,omitempty"` // Named sets of fields inherited by the commands that extend them
+
+	files     []string             // configuration files that were loaded
+	positions map[string]positions // where the values are defined in each file
+	unknown   ValidationErrors     // keys of the files that are not configuration fields
 }
 
 // Command represents the configuration for a single command
@@ -17,11 +38,208 @@ type Command struct {
 	Command []string `json:"command" yaml:"command"` // Array of command and arguments to execute
 
 	// Optional fields with default values
-	Title     string            `json:"title,omitempty" yaml:"title,omitempty"`         // Display name in the UI (defaults to `name`)
-	CWD       string            `json:"cwd,omitempty" yaml:"cwd,omitempty"`             // Working directory for the command (relative or absolute)
-	Env       map[string]string `json:"env,omitempty" yaml:"env,omitempty"`             // Environment variables to set for the command
-	Autostart *bool             `json:"autostart,omitempty" yaml:"autostart,omitempty"` // Whether to start the command automatically (default: `true`)
-	Killable  *bool             `json:"killable,omitempty" yaml:"killable,omitempty"`   // Whether the command can be killed manually (default: `true`)
+	Title      string            `json:"title,omitempty" yaml:"title,omitempty"`           // Display name in the UI (defaults to `name`)
+	CWD        string            `json:"cwd,omitempty" yaml:"cwd,omitempty"`               // Working directory for the command (relative or absolute)
+	Env        map[string]string `json:"env,omitempty" yaml:"env,omitempty"`               // Environment variables to set for the command, on top of the inherited ones
+	EnvFile    string            `json:"env_file,omitempty" yaml:"env_file,omitempty"`     // Dotenv file with variables to set for the command, overridden by `env`
+	Shell      bool              `json:"shell,omitempty" yaml:"shell,omitempty"`           // Whether to run the command with `$SHELL -c`, the elements are joined with spaces (default: `false`)
+	Autostart  *bool             `json:"autostart,omitempty" yaml:"autostart,omitempty"`   // Whether to start the command automatically (default: `true`)
+	Killable   *bool             `json:"killable,omitempty" yaml:"killable,omitempty"`     // Whether the command can be killed manually (default: `true`)
+	DependsOn  []string          `json:"depends_on,omitempty" yaml:"depends_on,omitempty"` // Names of commands that must be running before this one starts
+	Ready      *ReadyProbe       `json:"ready,omitempty" yaml:"ready,omitempty"`           // Probe that tells when the command is ready (default: ready once started)
+	Restart    *RestartPolicy    `json:"restart,omitempty" yaml:"restart,omitempty"`       // When to restart the command after it exits (default: never)
+	Log        *LogFile          `json:"log,omitempty" yaml:"log,omitempty"`               // File receiving a copy of the command output (default: none)
+	Scrollback *Scrollback       `json:"scrollback,omitempty" yaml:"scrollback,omitempty"` // Limits of the output history kept for the command (default: 10000 lines)
+	Extends    string            `json:"extends,omitempty" yaml:"extends,omitempty"`       // Template whose fields the command inherits
+
+	// Graceful stop
+	StopSignal  string   `json:"stop_signal,omitempty" yaml:"stop_signal,omitempty"`   // Signal asking the command to exit, e.g. `SIGINT` (default: `SIGTERM`)
+	StopTimeout string   `json:"stop_timeout,omitempty" yaml:"stop_timeout,omitempty"` // Time the command gets to exit before it is killed (default: `5s`)
+	StopCommand []string `json:"stop_command,omitempty" yaml:"stop_command,omitempty"` // Command run before the stop signal is sent, e.g. `["docker", "compose", "down"]`
+
+	source    source            // where the command was defined
+	inherited map[string]source // where the fields taken from templates and defaults were defined
+}
+
+// Scrollback represents the limits of the output history kept for a command
+type Scrollback struct {
+	Lines int `json:"lines,omitempty" yaml:"lines,omitempty"` // Number of lines kept in memory (default: `10000`)
+	Spill int `json:"spill,omitempty" yaml:"spill,omitempty"` // Number of older lines compressed to temporary files instead of being dropped (default: `0`)
+}
+
+// GetLines returns the number of scrollback lines kept in memory
+func (s *Scrollback) GetLines() int {
+	if s.Lines > 0 {
+		return s.Lines
+	}
+	return 10000
+}
+
+// Log file formats
+const (
+	LogFormatRaw   = "raw"
+	LogFormatPlain = "plain"
+)
+
+// LogFile represents a file that receives a copy of the command output
+type LogFile struct {
+	Path       string `json:"path" yaml:"path"`                                 // File to append to, relative to the working directory of the command
+	Format     string `json:"format,omitempty" yaml:"format,omitempty"`         // `raw` keeps the terminal escape sequences, `plain` strips them (default: `raw`)
+	Timestamps bool   `json:"timestamps,omitempty" yaml:"timestamps,omitempty"` // Whether to prefix every line with the time it was written (default: `false`)
+	MaxSize    string `json:"max_size,omitempty" yaml:"max_size,omitempty"`     // Size after which the file is rotated, e.g. `10MB` (default: no limit)
+	MaxAge     string `json:"max_age,omitempty" yaml:"max_age,omitempty"`       // Age after which the file is rotated, e.g. `24h` (default: no limit)
+	MaxFiles   int    `json:"max_files,omitempty" yaml:"max_files,omitempty"`   // Number of rotated files to keep (default: `5`)
+}
+
+// GetPath returns the log file path resolved against the working directory of the command
+func (l *LogFile) GetPath(cwd string) string {
+	if filepath.IsAbs(l.Path) {
+		return l.Path
+	}
+	return filepath.Join(cwd, l.Path)
+}
+
+// GetFormat returns the log file format
+func (l *LogFile) GetFormat() string {
+	if l.Format != "" {
+		return l.Format
+	}
+	return LogFormatRaw
+}
+
+// GetMaxSize returns the size in bytes after which the file is rotated, zero means no limit
+func (l *LogFile) GetMaxSize() int64 {
+	if size, err := parseSize(l.MaxSize); err == nil {
+		return size
+	}
+	return 0
+}
+
+// GetMaxAge returns the age after which the file is rotated, zero means no limit
+func (l *LogFile) GetMaxAge() time.Duration {
+	if d, err := time.ParseDuration(l.MaxAge); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// GetMaxFiles returns the number of rotated files to keep
+func (l *LogFile) GetMaxFiles() int {
+	if l.MaxFiles > 0 {
+		return l.MaxFiles
+	}
+	return 5
+}
+
+// Units accepted in sizes, from the longest suffix to the shortest
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size such as `512`, `64KB`, `10MB` or `1G`, units are powers of 1024
+func parseSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	number, unit := strings.TrimSpace(value), int64(1)
+	for _, u := range sizeUnits {
+		if rest, found := strings.CutSuffix(strings.ToUpper(number), u.suffix); found {
+			number, unit = strings.TrimSpace(rest), u.bytes
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+	return size * unit, nil
+}
+
+// Restart policies
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// RestartPolicy represents when and how often a command is restarted after it exits
+type RestartPolicy struct {
+	Policy     string `json:"policy" yaml:"policy"`                               // One of `never`, `on-failure` or `always`
+	MaxRetries int    `json:"max_retries,omitempty" yaml:"max_retries,omitempty"` // Maximum number of consecutive restarts (default: `0`, unlimited)
+	Backoff    string `json:"backoff,omitempty" yaml:"backoff,omitempty"`         // Delay before the first restart, doubled on every retry (default: `1s`)
+	MaxBackoff string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"` // Upper limit of the delay between restarts (default: `30s`)
+	ResetAfter string `json:"reset_after,omitempty" yaml:"reset_after,omitempty"` // Run time after which the retry count is reset (default: `1m`)
+}
+
+// GetPolicy returns the restart policy
+func (r *RestartPolicy) GetPolicy() string {
+	if r.Policy != "" {
+		return r.Policy
+	}
+	return RestartNever
+}
+
+// GetBackoff returns the delay before the first restart
+func (r *RestartPolicy) GetBackoff() time.Duration {
+	if d, err := time.ParseDuration(r.Backoff); err == nil && d > 0 {
+		return d
+	}
+	return time.Second
+}
+
+// GetMaxBackoff returns the upper limit of the delay between restarts
+func (r *RestartPolicy) GetMaxBackoff() time.Duration {
+	if d, err := time.ParseDuration(r.MaxBackoff); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// GetResetAfter returns the run time after which the retry count is reset
+func (r *RestartPolicy) GetResetAfter() time.Duration {
+	if d, err := time.ParseDuration(r.ResetAfter); err == nil && d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// ReadyProbe represents a readiness check for a command, exactly one of
+// TCP, HTTP, Log or Exec must be set
+type ReadyProbe struct {
+	TCP      string   `json:"tcp,omitempty" yaml:"tcp,omitempty"`           // Address to connect to, e.g. `localhost:5432`
+	HTTP     string   `json:"http,omitempty" yaml:"http,omitempty"`         // URL that must answer a GET request
+	Status   int      `json:"status,omitempty" yaml:"status,omitempty"`     // Expected HTTP status code (default: any 2xx)
+	Log      string   `json:"log,omitempty" yaml:"log,omitempty"`           // Regular expression matched against the command output
+	Exec     []string `json:"exec,omitempty" yaml:"exec,omitempty"`         // Command that must exit with status 0
+	Interval string   `json:"interval,omitempty" yaml:"interval,omitempty"` // Delay between checks (default: `1s`)
+	Timeout  string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`   // Time after which the probe fails (default: `60s`, `0` waits forever)
+}
+
+// GetInterval returns the delay between probe checks
+func (p *ReadyProbe) GetInterval() time.Duration {
+	if d, err := time.ParseDuration(p.Interval); err == nil && d > 0 {
+		return d
+	}
+	return time.Second
+}
+
+// GetTimeout returns the time after which the probe fails, zero means no timeout
+func (p *ReadyProbe) GetTimeout() time.Duration {
+	if d, err := time.ParseDuration(p.Timeout); err == nil && d >= 0 {
+		return d
+	}
+	return time.Minute
 }
 
 // GetTitle returns the command title or name if title is not set
@@ -32,6 +250,43 @@ func (c *Command) GetTitle() string {
 	return "→ " + c.Name
 }
 
+// GetArgs returns the command and arguments to execute, the shell running
+// the joined command in shell mode
+func (c *Command) GetArgs() []string {
+	if c.Shell {
+		return process.Shell(strings.Join(c.Command, " "))
+	}
+	return c.Command
+}
+
+// GetStopSignal returns the signal asking the command to exit
+func (c *Command) GetStopSignal() syscall.Signal {
+	if sig, err := process.ParseSignal(c.StopSignal); err == nil {
+		return sig
+	}
+	return syscall.SIGTERM
+}
+
+// GetStopTimeout returns the time the command gets to exit before it is killed
+func (c *Command) GetStopTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.StopTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Second
+}
+
+// GetStopArgs returns the stop command and its arguments, run by the shell
+// in shell mode, nil when the command has none
+func (c *Command) GetStopArgs() []string {
+	if len(c.StopCommand) == 0 {
+		return nil
+	}
+	if c.Shell {
+		return process.Shell(strings.Join(c.StopCommand, " "))
+	}
+	return c.StopCommand
+}
+
 // GetCWD returns the working directory or current directory
 func (c *Command) GetCWD(defaultCWD string) string {
 	if c.CWD != "" {
@@ -73,6 +328,16 @@ func (c *Command) Validate() error {
 		return fmt.Errorf("command '%s': first element of command array cannot be empty", c.Name)
 	}
 
+	if c.Ready != nil && c.Ready.Log != "" {
+		if _, err := regexp.Compile(c.Ready.Log); err != nil {
+			return fmt.Errorf("command '%s': invalid ready log pattern: %w", c.Name, err)
+		}
+	}
+
+	if c.Log != nil && c.Log.Path == "" {
+		return fmt.Errorf("command '%s': log path cannot be empty", c.Name)
+	}
+
 	return nil
 }
 
@@ -85,6 +350,9 @@ func (cfg *Config) Validate() error {
 	names := make(map[string]bool)
 	for i, cmd := range cfg.Commands {
 		if err := cmd.Validate(); err != nil {
+			if cmd.source.path != "" {
+				return fmt.Errorf("%s: %w", strings.TrimPrefix(cmd.source.file+": "+cmd.source.path, ": "), err)
+			}
 			return fmt.Errorf("command %d: %w", i+1, err)
 		}
 
@@ -94,5 +362,17 @@ func (cfg *Config) Validate() error {
 		names[cmd.Name] = true
 	}
 
+	if cfg.Keybindings != nil {
+		if err := cfg.Keybindings.Validate(); err != nil {
+			return fmt.Errorf("keybindings: %w", err)
+		}
+	}
+
+	if cfg.Clipboard != nil {
+		if err := cfg.Clipboard.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
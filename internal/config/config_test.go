@@ -1,7 +1,11 @@
 package config
 
 import (
+	"path/filepath"
+	"slices"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestConfig_Validate(t *testing.T) {
@@ -128,6 +132,53 @@ func TestCommand_GetTitle(t *testing.T) {
 	}
 }
 
+func TestCommand_GetArgs(t *testing.T) {
+	t.Setenv("SHELL", "/bin/zsh")
+
+	cmd := Command{Name: "test", Command: []string{"echo", "hello"}}
+	if got := cmd.GetArgs(); !slices.Equal(got, []string{"echo", "hello"}) {
+		t.Errorf("Command.GetArgs() = %q, want the command", got)
+	}
+
+	cmd = Command{Name: "test", Command: []string{"make build &&", "./server | tee log"}, Shell: true}
+	want := []string{"/bin/zsh", "-c", "make build && ./server | tee log"}
+	if got := cmd.GetArgs(); !slices.Equal(got, want) {
+		t.Errorf("Command.GetArgs() = %q, want %q", got, want)
+	}
+
+	t.Setenv("SHELL", "")
+	want = []string{"/bin/sh", "-c", "make build && ./server | tee log"}
+	if got := cmd.GetArgs(); !slices.Equal(got, want) {
+		t.Errorf("Command.GetArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestCommand_Stop(t *testing.T) {
+	cmd := Command{Name: "test", Command: []string{"npm", "start"}}
+	if got := cmd.GetStopSignal(); got != syscall.SIGTERM {
+		t.Errorf("Command.GetStopSignal() = %v, want %v", got, syscall.SIGTERM)
+	}
+	if got := cmd.GetStopTimeout(); got != 5*time.Second {
+		t.Errorf("Command.GetStopTimeout() = %v, want %v", got, 5*time.Second)
+	}
+	if got := cmd.GetStopArgs(); got != nil {
+		t.Errorf("Command.GetStopArgs() = %q, want nil", got)
+	}
+
+	t.Setenv("SHELL", "/bin/sh")
+	cmd.StopSignal, cmd.StopTimeout = "int", "30s"
+	cmd.StopCommand, cmd.Shell = []string{"docker compose", "down"}, true
+	if got := cmd.GetStopSignal(); got != syscall.SIGINT {
+		t.Errorf("Command.GetStopSignal() = %v, want %v", got, syscall.SIGINT)
+	}
+	if got := cmd.GetStopTimeout(); got != 30*time.Second {
+		t.Errorf("Command.GetStopTimeout() = %v, want %v", got, 30*time.Second)
+	}
+	if got, want := cmd.GetStopArgs(), []string{"/bin/sh", "-c", "docker compose down"}; !slices.Equal(got, want) {
+		t.Errorf("Command.GetStopArgs() = %q, want %q", got, want)
+	}
+}
+
 func TestCommand_IsAutostart(t *testing.T) {
 	trueVal := true
 	falseVal := false
@@ -162,3 +213,151 @@ func TestCommand_IsAutostart(t *testing.T) {
 		})
 	}
 }
+
+func TestReadyProbe_Durations(t *testing.T) {
+	tests := []struct {
+		name         string
+		probe        ReadyProbe
+		wantInterval time.Duration
+		wantTimeout  time.Duration
+	}{
+		{
+			name:         "defaults",
+			probe:        ReadyProbe{},
+			wantInterval: time.Second,
+			wantTimeout:  time.Minute,
+		},
+		{
+			name:         "custom values",
+			probe:        ReadyProbe{Interval: "250ms", Timeout: "2m"},
+			wantInterval: 250 * time.Millisecond,
+			wantTimeout:  2 * time.Minute,
+		},
+		{
+			name:         "zero timeout waits forever",
+			probe:        ReadyProbe{Timeout: "0"},
+			wantInterval: time.Second,
+			wantTimeout:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.probe.GetInterval(); got != tt.wantInterval {
+				t.Errorf("ReadyProbe.GetInterval() = %v, want %v", got, tt.wantInterval)
+			}
+			if got := tt.probe.GetTimeout(); got != tt.wantTimeout {
+				t.Errorf("ReadyProbe.GetTimeout() = %v, want %v", got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestRestartPolicy_Defaults(t *testing.T) {
+	restart := RestartPolicy{}
+
+	if got := restart.GetPolicy(); got != RestartNever {
+		t.Errorf("RestartPolicy.GetPolicy() = %v, want %v", got, RestartNever)
+	}
+	if got := restart.GetBackoff(); got != time.Second {
+		t.Errorf("RestartPolicy.GetBackoff() = %v, want %v", got, time.Second)
+	}
+	if got := restart.GetMaxBackoff(); got != 30*time.Second {
+		t.Errorf("RestartPolicy.GetMaxBackoff() = %v, want %v", got, 30*time.Second)
+	}
+	if got := restart.GetResetAfter(); got != time.Minute {
+		t.Errorf("RestartPolicy.GetResetAfter() = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestLogFile_Defaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		log         LogFile
+		wantPath    string
+		wantFormat  string
+		wantMaxSize int64
+		wantMaxAge  time.Duration
+		wantFiles   int
+	}{
+		{
+			name:       "defaults",
+			log:        LogFile{Path: "logs/api.log"},
+			wantPath:   "/srv/app/logs/api.log",
+			wantFormat: LogFormatRaw,
+			wantFiles:  5,
+		},
+		{
+			name:        "limits",
+			log:         LogFile{Path: "/var/log/api.log", Format: LogFormatPlain, MaxSize: "10MB", MaxAge: "24h", MaxFiles: 2},
+			wantPath:    "/var/log/api.log",
+			wantFormat:  LogFormatPlain,
+			wantMaxSize: 10 << 20,
+			wantMaxAge:  24 * time.Hour,
+			wantFiles:   2,
+		},
+		{
+			name:        "size in bytes",
+			log:         LogFile{Path: "api.log", MaxSize: "512"},
+			wantPath:    "/srv/app/api.log",
+			wantFormat:  LogFormatRaw,
+			wantMaxSize: 512,
+			wantFiles:   5,
+		},
+		{
+			name:        "lower case unit",
+			log:         LogFile{Path: "api.log", MaxSize: "64k"},
+			wantPath:    "/srv/app/api.log",
+			wantFormat:  LogFormatRaw,
+			wantMaxSize: 64 << 10,
+			wantFiles:   5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.log.GetPath("/srv/app"); got != tt.wantPath {
+				t.Errorf("LogFile.GetPath() = %v, want %v", got, tt.wantPath)
+			}
+			if got := tt.log.GetFormat(); got != tt.wantFormat {
+				t.Errorf("LogFile.GetFormat() = %v, want %v", got, tt.wantFormat)
+			}
+			if got := tt.log.GetMaxSize(); got != tt.wantMaxSize {
+				t.Errorf("LogFile.GetMaxSize() = %v, want %v", got, tt.wantMaxSize)
+			}
+			if got := tt.log.GetMaxAge(); got != tt.wantMaxAge {
+				t.Errorf("LogFile.GetMaxAge() = %v, want %v", got, tt.wantMaxAge)
+			}
+			if got := tt.log.GetMaxFiles(); got != tt.wantFiles {
+				t.Errorf("LogFile.GetMaxFiles() = %v, want %v", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestScrollback_Defaults(t *testing.T) {
+	if got := (&Scrollback{}).GetLines(); got != 10000 {
+		t.Errorf("Scrollback.GetLines() = %v, want %v", got, 10000)
+	}
+	if got := (&Scrollback{Lines: 500}).GetLines(); got != 500 {
+		t.Errorf("Scrollback.GetLines() = %v, want %v", got, 500)
+	}
+}
+
+func TestClipboard_Defaults(t *testing.T) {
+	c := &Clipboard{}
+	if got := c.GetBackend(); got != ClipboardAuto {
+		t.Errorf("Clipboard.GetBackend() = %v, want %v", got, ClipboardAuto)
+	}
+	if got := c.GetMaxSize(); got != 0 {
+		t.Errorf("Clipboard.GetMaxSize() = %v, want %v", got, 0)
+	}
+
+	c = &Clipboard{Backend: ClipboardFile, Path: "clip.txt", MaxSize: "64KB"}
+	if got := c.GetPath("/work"); got != filepath.Join("/work", "clip.txt") {
+		t.Errorf("Clipboard.GetPath() = %v, want %v", got, filepath.Join("/work", "clip.txt"))
+	}
+	if got := c.GetMaxSize(); got != 64<<10 {
+		t.Errorf("Clipboard.GetMaxSize() = %v, want %v", got, 64<<10)
+	}
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Interpolate replaces `${NAME}` with the value of the variable and
+// `${NAME:-default}` with the value, or the default when the variable is unset
+// or empty. `$$` is a literal `$`, other dollar signs are kept for the shell.
+// The names of the variables without a value or default are returned.
+func Interpolate(s string, lookup func(string) (string, bool)) (string, []string) {
+	var result strings.Builder
+	var unresolved []string
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			result.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			result.WriteByte('$')
+			i++
+
+		case '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				result.WriteString(s[i:])
+				return result.String(), unresolved
+			}
+			name, fallback, hasDefault := strings.Cut(s[i+2:end], ":-")
+			value, ok := lookup(name)
+			switch {
+			case ok && (value != "" || !hasDefault):
+				result.WriteString(value)
+			case hasDefault:
+				value, missing := Interpolate(fallback, lookup)
+				result.WriteString(value)
+				unresolved = append(unresolved, missing...)
+			default:
+				unresolved = append(unresolved, name)
+			}
+			i = end
+
+		default:
+			result.WriteByte('$')
+		}
+	}
+
+	return result.String(), unresolved
+}
+
+// Returns the index of the brace closing the one before start, -1 if there is none
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// ParseEnvFile parses a dotenv file: `KEY=value` lines with an optional
+// `export ` prefix, blank lines and `#` comments. Single quoted values are
+// literal, double quoted values support `\n`, `\t`, `\"` and `\\` escapes,
+// and unquoted values end at ` #`.
+func ParseEnvFile(data []byte) (map[string]string, error) {
+	env := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", number)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.IndexByte(value[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quote", number)
+			}
+			value = value[1 : end+1]
+
+		case strings.HasPrefix(value, `"`):
+			unquoted, ok := unquoteEnvValue(value[1:])
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated double quote", number)
+			}
+			value = unquoted
+
+		default:
+			if index := strings.Index(value, " #"); index >= 0 {
+				value = strings.TrimSpace(value[:index])
+			}
+		}
+
+		env[key] = value
+	}
+
+	return env, scanner.Err()
+}
+
+// Returns the double quoted value up to the closing quote with the escapes replaced
+func unquoteEnvValue(s string) (string, bool) {
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			return result.String(), true
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				result.WriteByte('\n')
+			case 't':
+				result.WriteByte('\t')
+			case '"', '\\':
+				result.WriteByte(s[i])
+			default:
+				result.WriteByte('\\')
+				result.WriteByte(s[i])
+			}
+		default:
+			result.WriteByte(s[i])
+		}
+	}
+	return "", false
+}
+
+// LoadEnvFile reads and parses a dotenv file, relative paths are resolved
+// against the base directory
+func LoadEnvFile(path string, baseCWD string) (map[string]string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseCWD, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	env, err := ParseEnvFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("env file '%s': %w", path, err)
+	}
+	return env, nil
+}
+
+// environment interpolates the variables of the configuration layer by layer:
+// the env file and the env of the configuration, then the ones of every
+// command. A layer sees the variables of the process and of the layers before
+// it. Problems are reported as validation errors.
+type environment struct {
+	baseCWD    string
+	unresolved ValidationErrors // variables without a value or default
+	files      ValidationErrors // env files that cannot be read
+}
+
+// Applies an env file and an env block on top of the variables, the unresolved
+// variables and unreadable files are recorded under the field prefix
+func (e *environment) layer(vars map[string]string, envFile string, env map[string]string, prefix string) map[string]string {
+	result := maps.Clone(vars)
+
+	if envFile != "" {
+		fromFile, err := LoadEnvFile(envFile, e.baseCWD)
+		if err != nil {
+			e.files = append(e.files, ValidationError{
+				Field:   prefix + "env_file",
+				Message: err.Error(),
+				Value:   envFile,
+			})
+		}
+		maps.Copy(result, fromFile)
+	}
+
+	// The values see the env file but not each other
+	lookup := e.lookup(maps.Clone(result))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		result[key] = e.interpolate(env[key], lookup, prefix+"env."+key)
+	}
+
+	return result
+}
+
+// Returns a lookup of the variables, falling back to the environment of the process
+func (e *environment) lookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+}
+
+// Interpolates the value and records the unresolved variables under the field
+func (e *environment) interpolate(value string, lookup func(string) (string, bool), field string) string {
+	result, unresolved := Interpolate(value, lookup)
+	for _, name := range unresolved {
+		e.unresolved = append(e.unresolved, ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("variable '%s' is not set and has no default", name),
+			Value:   value,
+		})
+	}
+	return result
+}
+
+// Interpolates the variables of the command and returns the copy, the env
+// of the command includes the shared variables
+func (e *environment) command(cmd Command, shared map[string]string, prefix string) Command {
+	vars := e.layer(shared, cmd.EnvFile, cmd.Env, prefix+".")
+	lookup := e.lookup(vars)
+
+	cmd.Command = slices.Clone(cmd.Command)
+	for i, part := range cmd.Command {
+		cmd.Command[i] = e.interpolate(part, lookup, fmt.Sprintf("%s.command[%d]", prefix, i))
+	}
+	cmd.StopCommand = slices.Clone(cmd.StopCommand)
+	for i, part := range cmd.StopCommand {
+		cmd.StopCommand[i] = e.interpolate(part, lookup, fmt.Sprintf("%s.stop_command[%d]", prefix, i))
+	}
+	cmd.CWD = e.interpolate(cmd.CWD, lookup, prefix+".cwd")
+	cmd.EnvFile = ""
+	cmd.Env = vars
+	if len(vars) == 0 {
+		cmd.Env = nil
+	}
+
+	return cmd
+}
+
+// Interpolates the variables of all commands
+func (e *environment) config(cfg *Config) *Config {
+	resolved := *cfg
+	shared := e.layer(map[string]string{}, cfg.EnvFile, cfg.Env, "")
+
+	resolved.Commands = make([]Command, len(cfg.Commands))
+	for i, cmd := range cfg.Commands {
+		resolved.Commands[i] = e.command(cmd, shared, fmt.Sprintf("commands[%d]", i))
+	}
+	resolved.Env = nil
+	resolved.EnvFile = ""
+
+	return &resolved
+}
+
+// Resolve returns a copy of the configuration with the variables applied: the
+// env files are loaded, the shared env is merged into the env of every
+// command, and `${NAME}` and `${NAME:-default}` in the command, stop command,
+// cwd and env values are replaced. Relative env files are resolved against the base
+// directory. Variables that are not set become empty strings, they are
+// reported by ValidateStrict. An env file that cannot be read is an error.
+func (cfg *Config) Resolve(baseCWD string) (*Config, error) {
+	env := &environment{baseCWD: baseCWD}
+	resolved := env.config(cfg)
+	if len(env.files) > 0 {
+		return nil, cfg.locate(env.files)
+	}
+
+	return resolved, nil
+}
+
+// validateVariables reports unresolved variables and unreadable env files,
+// relative env files are resolved against the working directory
+func validateVariables(cfg *Config) ValidationErrors {
+	env := &environment{}
+	env.config(cfg)
+	return append(env.files, env.unresolved...)
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"HOST": "localhost", "PORT": "8080", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		value      string
+		want       string
+		unresolved []string
+	}{
+		{value: "http://${HOST}:${PORT}", want: "http://localhost:8080"},
+		{value: "${MISSING:-3000}", want: "3000"},
+		{value: "${EMPTY:-fallback}", want: "fallback"},
+		{value: "${EMPTY}", want: ""},
+		{value: "${MISSING:-${HOST}}", want: "localhost"},
+		{value: "$HOME and $$ and $${HOST}", want: "$HOME and $ and ${HOST}"},
+		{value: "${MISSING}/bin", want: "/bin", unresolved: []string{"MISSING"}},
+		{value: "${MISSING:-${OTHER}}", want: "", unresolved: []string{"OTHER"}},
+		{value: "${HOST", want: "${HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, unresolved := Interpolate(tt.value, lookup)
+			if got != tt.want || !slices.Equal(unresolved, tt.unresolved) {
+				t.Errorf("Interpolate(%q) = %q, %q, want %q, %q", tt.value, got, unresolved, tt.want, tt.unresolved)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	data := []byte(`# database
+DB_HOST=localhost
+export DB_PORT = 5432
+DB_NAME=app # inline comment
+PASSWORD='p#ss $word'
+GREETING="hello\n\"world\""
+URL=http://x/#anchor
+EMPTY=
+`)
+
+	env, err := ParseEnvFile(data)
+	if err != nil {
+		t.Fatalf("ParseEnvFile() error = %v", err)
+	}
+
+	want := map[string]string{
+		"DB_HOST":  "localhost",
+		"DB_PORT":  "5432",
+		"DB_NAME":  "app",
+		"PASSWORD": "p#ss $word",
+		"GREETING": "hello\n\"world\"",
+		"URL":      "http://x/#anchor",
+		"EMPTY":    "",
+	}
+	if !maps.Equal(env, want) {
+		t.Errorf("ParseEnvFile() = %q, want %q", env, want)
+	}
+
+	for _, invalid := range []string{"NO_VALUE", "A B=c", `Q="open`, "S='open"} {
+		if _, err := ParseEnvFile([]byte(invalid)); err == nil {
+			t.Errorf("ParseEnvFile(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestConfig_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_HOST=db\nLEVEL=info\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MULTIPLEXER_TEST_ROOT", "/srv")
+
+	cfg := Config{
+		EnvFile: ".env",
+		Env:     map[string]string{"LEVEL": "debug", "DB_URL": "postgres://${DB_HOST}/app"},
+		Commands: []Command{
+			{
+				Name:    "api",
+				Command: []string{"./api", "--port", "${PORT:-8080}"},
+				CWD:     "${MULTIPLEXER_TEST_ROOT}/api",
+				Env:     map[string]string{"PORT": "9000", "LOG": "${LEVEL}"},
+			},
+			{Name: "web", Command: []string{"npm", "start"}},
+		},
+	}
+
+	resolved, err := cfg.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	api := resolved.Commands[0]
+	if !slices.Equal(api.Command, []string{"./api", "--port", "9000"}) {
+		t.Errorf("command = %q", api.Command)
+	}
+	if api.CWD != "/srv/api" {
+		t.Errorf("cwd = %q", api.CWD)
+	}
+	want := map[string]string{"DB_HOST": "db", "LEVEL": "debug", "DB_URL": "postgres://db/app", "PORT": "9000", "LOG": "debug"}
+	if !maps.Equal(api.Env, want) {
+		t.Errorf("env = %q, want %q", api.Env, want)
+	}
+	if resolved.Commands[1].Env["DB_URL"] != "postgres://db/app" {
+		t.Errorf("shared env not applied: %q", resolved.Commands[1].Env)
+	}
+	if cfg.Commands[0].Command[2] != "${PORT:-8080}" {
+		t.Errorf("Resolve() changed the original configuration")
+	}
+
+	cfg.Commands[1].EnvFile = "missing.env"
+	if _, err := cfg.Resolve(dir); err == nil {
+		t.Errorf("Resolve() expected error for a missing env file")
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// unknownFields reports the keys of a decoded document that are not fields
+// of the type they are decoded into, e.g. a misspelled `autostrat`
+func unknownFields(value any, t reflect.Type, path string, pos positions) ValidationErrors {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errors ValidationErrors
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			field := joinPath(path, key)
+			fieldType, ok := fields[key]
+			if !ok {
+				position, _ := pos.lookup(field)
+				errors = append(errors, ValidationError{
+					Position: position,
+					Field:    field,
+					Message:  "unknown field" + suggest(key, slices.Collect(maps.Keys(fields))),
+				})
+				continue
+			}
+			errors = append(errors, unknownFields(object[key], fieldType, field, pos)...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			errors = append(errors, unknownFields(object[key], t.Elem(), joinPath(path, key), pos)...)
+		}
+	case reflect.Slice:
+		// Arrays of tables are decoded from TOML as slices of maps
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice {
+			return nil
+		}
+		for i := range items.Len() {
+			index := fmt.Sprintf("%s[%d]", path, i)
+			errors = append(errors, unknownFields(items.Index(i).Interface(), t.Elem(), index, pos)...)
+		}
+	}
+	return errors
+}
+
+// Returns the types of the fields of a struct type by their names in the files
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.IsExported() && name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// Returns a hint naming the closest of the known names, empty when none is close
+func suggest(name string, known []string) string {
+	best, bestDistance := "", len(name)/2+1
+	for _, candidate := range slices.Sorted(slices.Values(known)) {
+		if distance := editDistance(strings.ToLower(name), candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean '%s'?", best)
+}
+
+// Returns the number of single character insertions, deletions,
+// substitutions and transpositions that turn a into b
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	rows := make([][]int, len(x)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(y)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(x)][len(y)]
+}
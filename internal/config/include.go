@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// source tells where a value of the configuration was defined
+type source struct {
+	file string // configuration file, empty for stdin
+	path string // path of the value within the file, e.g. `commands[2]`
+}
+
+// Files returns the configuration files that were loaded, the main file
+// followed by the included ones
+func (cfg *Config) Files() []string {
+	return cfg.files
+}
+
+// Records the file and path of the commands, templates and defaults parsed
+// from a file, and the positions of its values
+func (cfg *Config) setSource(file string, pos positions) {
+	cfg.positions = map[string]positions{file: pos}
+	for i := range cfg.unknown {
+		cfg.unknown[i].File = file
+	}
+	if file != "" {
+		cfg.files = []string{file}
+	}
+	for i := range cfg.Commands {
+		cfg.Commands[i].source = source{file: file, path: fmt.Sprintf("commands[%d]", i)}
+	}
+	for name, template := range cfg.Templates {
+		template.source = source{file: file, path: "templates." + name}
+		cfg.Templates[name] = template
+	}
+	if cfg.Defaults != nil {
+		cfg.Defaults.source = source{file: file, path: "defaults"}
+	}
+}
+
+// Loads the included files into the configuration, relative paths are
+// resolved against the directory of the including file. The files are
+// merged in order, the including file takes precedence over them.
+func (cfg *Config) loadIncludes(dir string, stack []string) error {
+	if len(cfg.Include) == 0 {
+		return nil
+	}
+
+	merged := &Config{}
+	for i, include := range cfg.Include {
+		path := include
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		included, err := loadInclude(path, stack)
+		if err != nil {
+			file := ""
+			if len(stack) > 0 {
+				file = stack[len(stack)-1]
+			}
+			return ValidationErrors{{
+				File:    file,
+				Field:   fmt.Sprintf("include[%d]", i),
+				Message: err.Error(),
+				Value:   include,
+			}}
+		}
+		merged = merged.overlay(included)
+	}
+
+	*cfg = *merged.overlay(cfg)
+	cfg.Include = nil
+	return nil
+}
+
+// Parses an included file with its own includes
+func loadInclude(path string, stack []string) (*Config, error) {
+	if slices.Contains(stack, path) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, path), " -> "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read included file: %w", err)
+	}
+
+	included, positions, err := parse(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	included.setSource(path, positions)
+
+	if err := included.loadIncludes(filepath.Dir(path), append(stack, path)); err != nil {
+		return nil, err
+	}
+	return included, nil
+}
+
+// Returns the configuration with the settings of top applied over the ones
+// of cfg: commands are appended, maps are merged and other settings replaced
+func (cfg *Config) overlay(top *Config) *Config {
+	result := *top
+	result.files = append(slices.Clone(top.files), cfg.files...)
+	result.Commands = append(slices.Clone(cfg.Commands), top.Commands...)
+	result.Env = mergeMaps(cfg.Env, top.Env)
+	result.Templates = mergeMaps(cfg.Templates, top.Templates)
+	result.Profiles = mergeMaps(cfg.Profiles, top.Profiles)
+	result.positions = mergeMaps(cfg.positions, top.positions)
+	result.unknown = append(slices.Clone(cfg.unknown), top.unknown...)
+
+	if result.EnvFile == "" {
+		result.EnvFile = cfg.EnvFile
+	}
+	if result.Keybindings == nil {
+		result.Keybindings = cfg.Keybindings
+	}
+	if result.Clipboard == nil {
+		result.Clipboard = cfg.Clipboard
+	}
+	if result.Defaults == nil {
+		result.Defaults = cfg.Defaults
+	} else if cfg.Defaults != nil {
+		defaults := result.Defaults.inherit(*cfg.Defaults)
+		result.Defaults = &defaults
+	}
+
+	return &result
+}
+
+// Returns the entries of both maps, the ones of top take precedence, nil if both are empty
+func mergeMaps[V any](base map[string]V, top map[string]V) map[string]V {
+	if len(base) == 0 && len(top) == 0 {
+		return nil
+	}
+	result := maps.Clone(base)
+	if result == nil {
+		result = map[string]V{}
+	}
+	maps.Copy(result, top)
+	return result
+}
+
+// Matches the fields of validation errors that belong to a command
+var commandField = regexp.MustCompile(`^commands\[(\d+)\]`)
+
+// locate points the errors at the file, path and position the value came
+// from: for commands, the command itself, or the template or defaults it
+// inherited the field from
+func (cfg *Config) locate(errors ValidationErrors) ValidationErrors {
+	for i, err := range errors {
+		if match := commandField.FindStringSubmatch(err.Field); match != nil {
+			if index, _ := strconv.Atoi(match[1]); index < len(cfg.Commands) {
+				cmd := &cfg.Commands[index]
+				rest := err.Field[len(match[0]):]
+				src := cmd.source
+				if field := strings.TrimPrefix(rest, "."); field != rest {
+					name := field[:strings.IndexAny(field+".[", ".[")]
+					if inherited, ok := cmd.inherited[name]; ok {
+						src = inherited
+					}
+				}
+				if src.path == "" {
+					continue // built in code rather than loaded
+				}
+
+				errors[i].File = src.file
+				errors[i].Field = src.path + rest
+				errors[i].Position = cfg.position(src.file, errors[i].Field)
+				continue
+			}
+		}
+
+		if err.File != "" {
+			errors[i].Position = cfg.position(err.File, err.Field)
+		} else {
+			errors[i].File, errors[i].Position = cfg.find(err.Field)
+		}
+	}
+	return errors
+}
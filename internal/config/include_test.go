@@ -0,0 +1,229 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Writes the files to a temporary directory and returns its path
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadFromFile_Include(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.yaml": `
+include: ["services/db.yaml", "shared.json"]
+env:
+  LEVEL: debug
+commands:
+  - name: api
+    command: ["./api"]
+    depends_on: ["db"]
+`,
+		"services/db.yaml": `
+env:
+  LEVEL: info
+  DB: postgres
+commands:
+  - name: db
+    command: ["postgres"]
+`,
+		"shared.json": `{"profiles": {"backend": ["api"]}, "clipboard": {"backend": "osc52"}}`,
+	})
+
+	cfg, err := LoadFromFile(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	var names []string
+	for _, cmd := range cfg.Commands {
+		names = append(names, cmd.Name)
+	}
+	if !slices.Equal(names, []string{"db", "api"}) {
+		t.Errorf("commands = %v, want [db api]", names)
+	}
+	if cfg.Env["LEVEL"] != "debug" || cfg.Env["DB"] != "postgres" {
+		t.Errorf("env = %v, want the including file to take precedence", cfg.Env)
+	}
+	if cfg.Clipboard == nil || cfg.Clipboard.Backend != "osc52" {
+		t.Errorf("clipboard not included: %v", cfg.Clipboard)
+	}
+	if len(cfg.Files()) != 3 || cfg.Files()[0] != filepath.Join(dir, "main.yaml") {
+		t.Errorf("files = %v", cfg.Files())
+	}
+}
+
+func TestLoadFromFile_IncludeErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"missing.yaml": "include: [nope.yaml]\ncommands: [{name: a, command: [a]}]",
+		"a.yaml":       "include: [b.yaml]\ncommands: [{name: a, command: [a]}]",
+		"b.yaml":       "include: [a.yaml]",
+	})
+
+	tests := []struct {
+		file    string
+		wantErr string
+	}{
+		{file: "missing.yaml", wantErr: "missing.yaml: field 'include[0]': failed to read included file"},
+		{file: "a.yaml", wantErr: "include cycle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := LoadFromFile(filepath.Join(dir, tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFromFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromReader_Templates(t *testing.T) {
+	yamlConfig := `
+defaults:
+  cwd: ./services
+  restart:
+    policy: on-failure
+  env:
+    LEVEL: info
+templates:
+  go:
+    command: ["go", "run", "."]
+    env:
+      GOFLAGS: -race
+  go-debug:
+    extends: go
+    env:
+      LEVEL: debug
+commands:
+  - name: api
+    extends: go-debug
+    cwd: ./api
+  - name: worker
+    extends: go
+    command: ["go", "run", "./cmd/worker"]
+  - name: web
+    command: ["npm", "start"]
+`
+
+	cfg, err := LoadFromReader(strings.NewReader(yamlConfig), "yaml")
+	if err != nil {
+		t.Fatalf("LoadFromReader() error = %v", err)
+	}
+
+	api, worker, web := cfg.Commands[0], cfg.Commands[1], cfg.Commands[2]
+	if !slices.Equal(api.Command, []string{"go", "run", "."}) || api.CWD != "./api" {
+		t.Errorf("api = %v in %s", api.Command, api.CWD)
+	}
+	if api.Env["LEVEL"] != "debug" || api.Env["GOFLAGS"] != "-race" {
+		t.Errorf("api env = %v", api.Env)
+	}
+	if api.Restart == nil || api.Restart.Policy != RestartOnFailure {
+		t.Errorf("api did not inherit the defaults")
+	}
+	if !slices.Equal(worker.Command, []string{"go", "run", "./cmd/worker"}) || worker.Env["LEVEL"] != "info" {
+		t.Errorf("worker = %v with %v", worker.Command, worker.Env)
+	}
+	if web.CWD != "./services" || web.Env["GOFLAGS"] != "" {
+		t.Errorf("web = %s with %v", web.CWD, web.Env)
+	}
+
+	for _, invalid := range []string{
+		"commands: [{name: a, command: [a], extends: missing}]",
+		"templates: {x: {extends: y}, y: {extends: x}}\ncommands: [{name: a, command: [a], extends: x}]",
+	} {
+		if _, err := LoadFromReader(strings.NewReader(invalid), "yaml"); err == nil {
+			t.Errorf("LoadFromReader(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestConfig_SelectProfiles(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string][]string{
+			"backend":  {"api"},
+			"frontend": {"web"},
+		},
+		Commands: []Command{
+			{Name: "db", Command: []string{"postgres"}},
+			{Name: "cache", Command: []string{"redis"}},
+			{Name: "api", Command: []string{"./api"}, DependsOn: []string{"db"}},
+			{Name: "web", Command: []string{"npm", "start"}},
+		},
+	}
+
+	selected := cfg
+	if err := selected.SelectProfiles([]string{"backend"}); err != nil {
+		t.Fatalf("SelectProfiles() error = %v", err)
+	}
+	var names []string
+	for _, cmd := range selected.Commands {
+		names = append(names, cmd.Name)
+	}
+	if !slices.Equal(names, []string{"db", "api"}) {
+		t.Errorf("commands = %v, want [db api]", names)
+	}
+	if len(cfg.Commands) != 4 {
+		t.Errorf("SelectProfiles() changed the commands of the original configuration")
+	}
+
+	if err := cfg.SelectProfiles([]string{"mobile"}); err == nil {
+		t.Errorf("SelectProfiles() expected error for an unknown profile")
+	}
+
+	cfg.Profiles["broken"] = []string{"missing"}
+	err := cfg.ValidateStrict()
+	if err == nil || !strings.Contains(err.Error(), "profiles.broken[0]") {
+		t.Errorf("ValidateStrict() error = %v, want the unknown profile command", err)
+	}
+}
+
+func TestConfig_ValidateStrict_Location(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.yaml": `
+include: [templates.yaml]
+commands:
+  - name: bad name
+    command: ["./api"]
+  - name: worker
+    extends: flaky
+`,
+		"templates.yaml": `
+templates:
+  flaky:
+    command: ["./worker"]
+    restart:
+      policy: sometimes
+`,
+	})
+
+	_, err := LoadFromFile(filepath.Join(dir, "main.yaml"))
+	if err == nil {
+		t.Fatal("LoadFromFile() expected errors")
+	}
+	message := err.Error()
+	for _, want := range []string{
+		filepath.Join(dir, "main.yaml") + ":4:5: field 'commands[0].name'",
+		filepath.Join(dir, "templates.yaml") + ":6:7: field 'templates.flaky.restart.policy'",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("LoadFromFile() error = %v, want %q", message, want)
+		}
+	}
+}
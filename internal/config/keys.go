@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Key binding actions
+const (
+	ActionNone       = "none"        // removes a default binding
+	ActionUp         = "up"          // select the previous pane
+	ActionDown       = "down"        // select the next pane
+	ActionSelect     = "select"      // focus or start the selected pane, reset its scroll position or copy the selection
+	ActionSidebar    = "sidebar"     // leave the focused pane
+	ActionKill       = "kill"        // kill the selected pane, or cancel its pending start
+	ActionScrollUp   = "scroll-up"   // scroll up by half a screen
+	ActionScrollDown = "scroll-down" // scroll down by half a screen
+	ActionSplit      = "split"       // pin the selected pane to the split layouts or unpin it
+	ActionLayout     = "layout"      // switch to the next layout
+	ActionNextSplit  = "next-split"  // select the next pane shown in the splits
+	ActionDetach     = "detach"      // detach from the session
+	ActionQuit       = "quit"        // exit the multiplexer
+
+	ActionSearch         = "search"          // search the scrollback of the selected pane
+	ActionSearchBackward = "search-backward" // search the scrollback towards older lines
+	ActionSearchNext     = "search-next"     // jump to the next search match
+	ActionSearchPrev     = "search-prev"     // jump to the previous search match
+	ActionSearchClear    = "search-clear"    // remove the search highlighting
+
+	ActionCopyMode = "copy-mode" // move a cursor over the output of the selected pane to select and copy text
+
+	ActionNewPane = "new-pane" // ask for the command line, name and working directory of a new pane
+	ActionRemove  = "remove"   // remove the selected pane once its process has ended
+)
+
+// actions lists the valid key binding actions
+var actions = []string{
+	ActionNone, ActionUp, ActionDown, ActionSelect, ActionSidebar, ActionKill, ActionScrollUp,
+	ActionScrollDown, ActionSplit, ActionLayout, ActionNextSplit, ActionDetach, ActionQuit,
+	ActionSearch, ActionSearchBackward, ActionSearchNext, ActionSearchPrev, ActionSearchClear,
+	ActionCopyMode, ActionNewPane, ActionRemove,
+}
+
+// Named keys, a chord is either one of them or a single character
+var keyNames = []string{
+	"enter", "tab", "esc", "space", "backspace", "delete", "insert",
+	"up", "down", "left", "right", "home", "end", "pgup", "pgdn",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+}
+
+// Alternative spellings of named keys
+var keyAliases = map[string]string{
+	"return":   "enter",
+	"escape":   "esc",
+	"del":      "delete",
+	"ins":      "insert",
+	"pageup":   "pgup",
+	"pagedown": "pgdn",
+}
+
+// Control characters that terminals send for named keys
+var ctrlAliases = map[string]string{
+	"h": "backspace",
+	"i": "tab",
+	"m": "enter",
+	"[": "esc",
+}
+
+// Keybindings maps key chords such as `j`, `ctrl-a`, `alt-x` or `shift-tab` to actions.
+// The bindings are merged with the default ones, `none` removes a default binding.
+type Keybindings struct {
+	Prefix   string            `json:"prefix,omitempty" yaml:"prefix,omitempty"`     // Key that starts a tmux-like prefix sequence, e.g. `ctrl-b` (default: none)
+	Sidebar  map[string]string `json:"sidebar,omitempty" yaml:"sidebar,omitempty"`   // Bindings active in the sidebar
+	Focused  map[string]string `json:"focused,omitempty" yaml:"focused,omitempty"`   // Bindings active in a focused pane, other keys are sent to the pane
+	Prefixed map[string]string `json:"prefixed,omitempty" yaml:"prefixed,omitempty"` // Bindings active for the key pressed after the prefix
+}
+
+// NormalizeChord returns the canonical form of a key chord: the `ctrl-`, `alt-`
+// and `shift-` modifiers in this order followed by a named key or a single
+// character. Shifted letters are written as upper case letters.
+func NormalizeChord(chord string) (string, error) {
+	var ctrl, alt, shift bool
+	rest := chord
+	for {
+		modifier, key, found := strings.Cut(rest, "-")
+		if !found || key == "" {
+			break
+		}
+		switch strings.ToLower(modifier) {
+		case "ctrl":
+			ctrl = true
+		case "alt":
+			alt = true
+		case "shift":
+			shift = true
+		default:
+			return "", fmt.Errorf("unknown modifier '%s'", modifier)
+		}
+		rest = key
+	}
+
+	key := rest
+	if utf8.RuneCountInString(key) != 1 {
+		key = strings.ToLower(key)
+		if alias, ok := keyAliases[key]; ok {
+			key = alias
+		}
+		if !slices.Contains(keyNames, key) {
+			return "", fmt.Errorf("unknown key '%s'", rest)
+		}
+	}
+
+	if key == " " {
+		key = "space"
+	}
+
+	if utf8.RuneCountInString(key) == 1 {
+		r, _ := utf8.DecodeRuneInString(key)
+		if shift {
+			if !unicode.IsLetter(r) {
+				return "", fmt.Errorf("shift only applies to letters and named keys")
+			}
+			key = string(unicode.ToUpper(r))
+			shift = false
+		}
+		if ctrl {
+			lower := strings.ToLower(key)
+			if alias, ok := ctrlAliases[lower]; ok {
+				key = alias
+				ctrl = false
+			} else if len(lower) != 1 || lower[0] < 'a' || lower[0] > 'z' {
+				return "", fmt.Errorf("ctrl only applies to the letters a-z and named keys")
+			} else {
+				key = lower
+			}
+		}
+	}
+
+	var result strings.Builder
+	if ctrl {
+		result.WriteString("ctrl-")
+	}
+	if alt {
+		result.WriteString("alt-")
+	}
+	if shift {
+		result.WriteString("shift-")
+	}
+	result.WriteString(key)
+	return result.String(), nil
+}
+
+// Validate checks the key chords and actions of the bindings
+func (k *Keybindings) Validate() error {
+	if k.Prefix != "" {
+		if _, err := NormalizeChord(k.Prefix); err != nil {
+			return fmt.Errorf("prefix '%s': %w", k.Prefix, err)
+		}
+	}
+
+	for _, mode := range []map[string]string{k.Sidebar, k.Focused, k.Prefixed} {
+		for chord, action := range mode {
+			if _, err := NormalizeChord(chord); err != nil {
+				return fmt.Errorf("key '%s': %w", chord, err)
+			}
+			if !slices.Contains(actions, action) {
+				return fmt.Errorf("key '%s': unknown action '%s'", chord, action)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import "testing"
+
+func TestNormalizeChord(t *testing.T) {
+	tests := []struct {
+		chord   string
+		want    string
+		wantErr bool
+	}{
+		{chord: "j", want: "j"},
+		{chord: "J", want: "J"},
+		{chord: "shift-j", want: "J"},
+		{chord: "Ctrl-A", want: "ctrl-a"},
+		{chord: "alt-ctrl-x", want: "ctrl-alt-x"},
+		{chord: "ctrl-shift-up", want: "ctrl-shift-up"},
+		{chord: "Return", want: "enter"},
+		{chord: "ctrl-i", want: "tab"},
+		{chord: "ctrl-h", want: "backspace"},
+		{chord: "shift-tab", want: "shift-tab"},
+		{chord: " ", want: "space"},
+		{chord: "ctrl-space", want: "ctrl-space"},
+		{chord: "-", want: "-"},
+		{chord: "alt--", want: "alt--"},
+		{chord: "F5", want: "f5"},
+		{chord: "", wantErr: true},
+		{chord: "ctrl-1", wantErr: true},
+		{chord: "shift-1", wantErr: true},
+		{chord: "super-a", wantErr: true},
+		{chord: "ctrl-pause", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chord, func(t *testing.T) {
+			got, err := NormalizeChord(tt.chord)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeChord(%q) = %q, want error", tt.chord, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizeChord(%q) = %q, %v, want %q", tt.chord, got, err, tt.want)
+			}
+		})
+	}
+}
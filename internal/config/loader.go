@@ -2,16 +2,20 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
-// LoadFromFile loads configuration from a file (JSON or YAML)
+// LoadFromFile loads configuration from a file (JSON, YAML or TOML), included files
+// are resolved against its directory
 func LoadFromFile(path string) (*Config, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -19,7 +23,7 @@ func LoadFromFile(path string) (*Config, error) {
 	}
 	defer file.Close()
 
-	return LoadFromReader(file, filepath.Ext(path))
+	return load(file, filepath.Ext(path), path)
 }
 
 // LoadFromStdin loads configuration from stdin
@@ -30,8 +34,15 @@ func LoadFromStdin(format string) (*Config, error) {
 	return LoadFromReader(os.Stdin, format)
 }
 
-// LoadFromReader loads configuration from an io.Reader
+// LoadFromReader loads configuration from an io.Reader, included files are
+// resolved against the working directory
 func LoadFromReader(reader io.Reader, format string) (*Config, error) {
+	return load(reader, format, "")
+}
+
+// Loads the configuration read from the file, with its includes and templates
+// applied. The path is empty when the configuration does not come from a file.
+func load(reader io.Reader, format string, path string) (*Config, error) {
 	data, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config data: %w", err)
@@ -41,31 +52,101 @@ func LoadFromReader(reader io.Reader, format string) (*Config, error) {
 		return nil, fmt.Errorf("config data is empty")
 	}
 
+	config, positions, err := parse(data, format)
+	if err != nil {
+		return nil, err
+	}
+	config.setSource(path, positions)
+
+	dir, stack := ".", []string(nil)
+	if path != "" {
+		dir, stack = filepath.Dir(path), []string{path}
+	}
+	if err := config.loadIncludes(dir, stack); err != nil {
+		return nil, fmt.Errorf("config include failed: %w", err)
+	}
+	if err := config.applyTemplates(); err != nil {
+		return nil, fmt.Errorf("config template failed: %w", err)
+	}
+
+	if err := config.ValidateStrict(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return config, nil
+}
+
+// Decodes the configuration data in the format, without includes and
+// templates, along with the positions of its values when the format tells
+// them. The keys that are not configuration fields are recorded in the
+// configuration and reported by ValidateStrict.
+func parse(data []byte, format string) (*Config, positions, error) {
 	// Default to JSON if format is empty
 	if format == "" {
 		format = "json"
 	}
 
 	var config Config
+	var document any
+	var pos positions
 
 	switch strings.ToLower(format) {
 	case ".json", "json":
 		if err := json.Unmarshal(data, &config); err != nil {
-			return nil, fmt.Errorf("failed to parse JSON config: %w", err)
+			return nil, nil, fmt.Errorf("failed to parse JSON config: %w", jsonError(data, err))
+		}
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse JSON config: %w", jsonError(data, err))
 		}
+		pos = jsonPositions(data)
 	case ".yaml", ".yml", "yaml", "yml":
-		if err := yaml.Unmarshal(data, &config); err != nil {
-			return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse YAML config: %w", err)
+		}
+		if node.Kind != 0 { // only comments
+			if err := node.Decode(&config); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse YAML config: %w", err)
+			}
+			if err := node.Decode(&document); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse YAML config: %w", err)
+			}
+		}
+		pos = yamlPositions(&node)
+	case ".toml", "toml":
+		// Decoded through JSON so the fields keep a single set of names
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse TOML config: %w", err)
+		}
+		converted, err := json.Marshal(document)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse TOML config: %w", err)
 		}
+		if err := json.Unmarshal(converted, &config); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse TOML config: %w", err)
+		}
+		pos = tomlPositions(data)
 	default:
-		return nil, fmt.Errorf("unsupported config format '%s', supported formats: json, yaml, yml", format)
+		return nil, nil, fmt.Errorf("unsupported config format '%s', supported formats: json, yaml, yml, toml", format)
 	}
 
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
+	config.unknown = unknownFields(document, reflect.TypeOf(config), "", pos)
+	return &config, pos, nil
+}
 
-	return &config, nil
+// Adds the position of the offset where decoding failed to JSON errors
+func jsonError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		pos := offsetPosition(data, int(syntaxErr.Offset))
+		return fmt.Errorf("line %d, column %d: %w", pos.Line, pos.Column, err)
+	case errors.As(err, &typeErr):
+		pos := offsetPosition(data, int(typeErr.Offset))
+		return fmt.Errorf("line %d, column %d: %w", pos.Line, pos.Column, err)
+	}
+	return err
 }
 
 // Load is a convenience function that loads config from file or stdin
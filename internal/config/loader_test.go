@@ -11,8 +11,7 @@ func TestLoadFromReader_JSON(t *testing.T) {
 			{
 				"name": "test",
 				"command": ["echo", "hello"],
-				"title": "Test Command",
-				"prompt": "🚀"
+				"title": "Test Command"
 			}
 		]
 	}`
@@ -44,7 +43,6 @@ commands:
   - name: test
     command: ["echo", "hello"]
     title: Test Command
-    prompt: "🚀"
     autostart: false
 `
 
@@ -69,6 +67,116 @@ commands:
 	}
 }
 
+func TestLoadFromReader_TOML(t *testing.T) {
+	tomlConfig := `
+[env]
+LOG_LEVEL = "debug"
+
+[[commands]]
+name = "test"
+command = ["echo", "hello"]
+title = "Test Command"
+autostart = false
+
+[commands.restart]
+policy = "on-failure"
+max_retries = 3
+`
+
+	reader := strings.NewReader(tomlConfig)
+	config, err := LoadFromReader(reader, "toml")
+
+	if err != nil {
+		t.Fatalf("LoadFromReader() error = %v", err)
+	}
+
+	if len(config.Commands) != 1 {
+		t.Fatalf("Expected 1 command, got %d", len(config.Commands))
+	}
+
+	cmd := config.Commands[0]
+	if cmd.Name != "test" || cmd.GetTitle() != "Test Command" || cmd.IsAutostart() {
+		t.Errorf("Unexpected command %+v", cmd)
+	}
+
+	if cmd.Restart == nil || cmd.Restart.Policy != RestartOnFailure || cmd.Restart.MaxRetries != 3 {
+		t.Errorf("Expected the on-failure restart policy, got %+v", cmd.Restart)
+	}
+
+	if config.Env["LOG_LEVEL"] != "debug" {
+		t.Errorf("Expected env LOG_LEVEL=debug, got %v", config.Env)
+	}
+}
+
+func TestLoadFromReader_UnknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		config  string
+		wantErr []string
+	}{
+		{
+			name:   "json",
+			format: "json",
+			config: `{
+  "commands": [
+    {"name": "test", "command": ["echo"], "autostrat": false}
+  ],
+  "prompt": "🚀"
+}`,
+			wantErr: []string{
+				"line 3, column 43: field 'commands[0].autostrat': unknown field, did you mean 'autostart'?",
+				"line 5, column 3: field 'prompt': unknown field",
+			},
+		},
+		{
+			name:   "yaml",
+			format: "yaml",
+			config: `commands:
+  - name: test
+    command: [echo]
+    ready:
+      htpp: http://localhost:8080
+templates:
+  base:
+    dependson: [db]
+`,
+			wantErr: []string{
+				"line 5, column 7: field 'commands[0].ready.htpp': unknown field, did you mean 'http'?",
+				"line 8, column 5: field 'templates.base.dependson': unknown field, did you mean 'depends_on'?",
+			},
+		},
+		{
+			name:   "toml",
+			format: "toml",
+			config: `[[commands]]
+name = "test"
+command = ["echo"]
+[commands.log]
+path = "test.log"
+formt = "plain"
+`,
+			wantErr: []string{
+				"field 'commands[0].log.formt': unknown field, did you mean 'format'?",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromReader(strings.NewReader(tt.config), tt.format)
+			if err == nil {
+				t.Fatal("LoadFromReader() expected an error for unknown fields")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadFromReader() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
 func TestLoadFromReader_InvalidJSON(t *testing.T) {
 	invalidJSON := `{"commands": [{"name": "test", "command":}]}`
 
@@ -96,6 +204,21 @@ commands:
 	}
 }
 
+func TestLoadFromReader_InvalidTOML(t *testing.T) {
+	invalidTOML := `
+[[commands]]
+name = "test"
+command = ["echo", "hello"
+`
+
+	reader := strings.NewReader(invalidTOML)
+	_, err := LoadFromReader(reader, "toml")
+
+	if err == nil {
+		t.Error("Expected error for invalid TOML, got nil")
+	}
+}
+
 func TestLoadFromReader_ValidationError(t *testing.T) {
 	invalidConfig := `{
 		"commands": [
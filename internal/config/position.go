@@ -0,0 +1,370 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a line and column in a configuration file, both counted from 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+// positions maps the paths of the values in a file, e.g. `commands[2].ready`,
+// to where they are defined: the key of a mapping entry or the sequence item
+type positions map[string]Position
+
+// Returns the position of the path, or of its closest parent found in the
+// file, the start of the document for settings that are missing
+func (p positions) lookup(path string) (Position, bool) {
+	path = mapKeyIndex.ReplaceAllString(path, ".$1")
+	for {
+		if pos, ok := p[path]; ok {
+			return pos, true
+		}
+		if path == "" {
+			return Position{}, false
+		}
+		path = path[:max(strings.LastIndexAny(path, ".["), 0)]
+	}
+}
+
+// Matches map keys written as indices in the fields of validation errors, e.g. `keybindings.sidebar[ctrl-c]`
+var mapKeyIndex = regexp.MustCompile(`\[([^\]]*[^\]0-9][^\]]*)\]`)
+
+// Joins the path of a value and the key of one of its entries
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Collects the positions of the values of a YAML document
+func yamlPositions(document *yaml.Node) positions {
+	result := positions{}
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				result[path] = Position{Line: child.Line, Column: child.Column}
+				walk(child, path)
+			}
+		case yaml.AliasNode:
+			walk(node.Alias, path)
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := joinPath(path, node.Content[i].Value)
+				result[key] = Position{Line: node.Content[i].Line, Column: node.Content[i].Column}
+				walk(node.Content[i+1], key)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				index := fmt.Sprintf("%s[%d]", path, i)
+				result[index] = Position{Line: item.Line, Column: item.Column}
+				walk(item, index)
+			}
+		}
+	}
+	walk(document, "")
+	return result
+}
+
+// Collects the positions of the values of a JSON document
+func jsonPositions(data []byte) positions {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	result := positions{}
+
+	// The decoder reports where the previous token ended, the next one
+	// starts after the separators
+	next := func() Position {
+		offset := int(decoder.InputOffset())
+		for offset < len(data) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+			offset++
+		}
+		return offsetPosition(data, offset)
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				pos := next()
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				name := joinPath(path, fmt.Sprint(key))
+				result[name] = pos
+				if err := walk(name); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				index := fmt.Sprintf("%s[%d]", path, i)
+				result[index] = next()
+				if err := walk(index); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+
+		_, err = decoder.Token() // closing delimiter
+		return err
+	}
+
+	result[""] = next()
+	if err := walk(""); err != nil {
+		return nil
+	}
+	return result
+}
+
+// Collects the positions of the values of a TOML document. The decoder does
+// not tell them, so the document is scanned again, it is known to be valid.
+func tomlPositions(data []byte) positions {
+	result := positions{}
+	offset := 0
+
+	// Skips spaces, and line breaks and comments if lines is set
+	skip := func(lines bool) {
+		for offset < len(data) {
+			switch c := data[offset]; {
+			case c == ' ' || c == '\t':
+				offset++
+			case lines && (c == '\r' || c == '\n'):
+				offset++
+			case lines && c == '#':
+				for offset < len(data) && data[offset] != '\n' {
+					offset++
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	// Reads a quoted string, the offset is on the opening quote
+	readString := func() string {
+		quote := data[offset]
+		delimiter := []byte{quote}
+		if bytes.HasPrefix(data[offset:], []byte{quote, quote, quote}) {
+			delimiter = []byte{quote, quote, quote}
+		}
+		offset += len(delimiter)
+		start := offset
+		for offset < len(data) && !bytes.HasPrefix(data[offset:], delimiter) {
+			if quote == '"' && data[offset] == '\\' {
+				offset++
+			}
+			offset++
+		}
+		// Quotes right before the closing delimiter belong to the string
+		for len(delimiter) == 3 && offset < len(data) && bytes.HasPrefix(data[offset+1:], delimiter) {
+			offset++
+		}
+		content := data[start:min(offset, len(data))]
+		offset += len(delimiter)
+
+		if quote == '"' {
+			var value string
+			if json.Unmarshal(append(append([]byte{'"'}, content...), '"'), &value) == nil {
+				return value
+			}
+		}
+		return string(content)
+	}
+
+	// Reads a dotted key and returns its parts
+	readKey := func() []string {
+		var parts []string
+		for offset < len(data) {
+			skip(false)
+			if offset < len(data) && (data[offset] == '"' || data[offset] == '\'') {
+				parts = append(parts, readString())
+			} else {
+				start := offset
+				for offset < len(data) && isBareKey(data[offset]) {
+					offset++
+				}
+				parts = append(parts, string(data[start:offset]))
+			}
+			skip(false)
+			if offset >= len(data) || data[offset] != '.' {
+				return parts
+			}
+			offset++
+		}
+		return parts
+	}
+
+	// Skips a value, recording the positions of the items of arrays and tables
+	var readValue func(path string)
+	readValue = func(path string) {
+		if offset >= len(data) {
+			return
+		}
+		switch data[offset] {
+		case '"', '\'':
+			readString()
+		case '[':
+			offset++
+			for i := 0; ; i++ {
+				skip(true)
+				if offset >= len(data) || data[offset] == ']' {
+					break
+				}
+				index := fmt.Sprintf("%s[%d]", path, i)
+				result[index] = offsetPosition(data, offset)
+				readValue(index)
+				skip(true)
+				if offset < len(data) && data[offset] == ',' {
+					offset++
+				}
+			}
+			offset++
+		case '{':
+			offset++
+			for {
+				skip(true)
+				if offset >= len(data) || data[offset] == '}' {
+					break
+				}
+				pos := offsetPosition(data, offset)
+				key := path
+				for _, part := range readKey() {
+					key = joinPath(key, part)
+					if _, ok := result[key]; !ok {
+						result[key] = pos
+					}
+				}
+				offset++ // =
+				skip(false)
+				readValue(key)
+				skip(true)
+				if offset < len(data) && data[offset] == ',' {
+					offset++
+				}
+			}
+			offset++
+		default:
+			for offset < len(data) && strings.IndexByte(",]}#\r\n", data[offset]) < 0 {
+				offset++
+			}
+		}
+	}
+
+	table := ""
+	arrays := map[string]int{} // number of tables in each array of tables
+	for {
+		skip(true)
+		if offset >= len(data) {
+			break
+		}
+		pos := offsetPosition(data, offset)
+		if _, ok := result[""]; !ok {
+			result[""] = pos
+		}
+
+		if data[offset] != '[' {
+			key := table
+			for _, part := range readKey() {
+				key = joinPath(key, part)
+				if _, ok := result[key]; !ok {
+					result[key] = pos
+				}
+			}
+			offset++ // =
+			skip(false)
+			readValue(key)
+			continue
+		}
+
+		// Table headers, tables nested in an array of tables belong to its last table
+		array := bytes.HasPrefix(data[offset:], []byte("[["))
+		if array {
+			offset += 2
+		} else {
+			offset++
+		}
+		parts := readKey()
+		table = ""
+		for i, part := range parts {
+			table = joinPath(table, part)
+			switch count, ok := arrays[table]; {
+			case array && i == len(parts)-1:
+				arrays[table] = count + 1
+				if _, ok := result[table]; !ok {
+					result[table] = pos
+				}
+				table = fmt.Sprintf("%s[%d]", table, count)
+			case ok:
+				table = fmt.Sprintf("%s[%d]", table, count-1)
+			}
+			if _, ok := result[table]; !ok {
+				result[table] = pos
+			}
+		}
+		for offset < len(data) && data[offset] == ']' {
+			offset++
+		}
+	}
+	return result
+}
+
+// Reports whether the character may appear in a bare TOML key
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// Converts a byte offset into a position, columns count characters
+func offsetPosition(data []byte, offset int) Position {
+	before := data[:min(offset, len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	return Position{Line: line, Column: column}
+}
+
+// Returns the position of the path in the file, zero when unknown
+func (cfg *Config) position(file string, path string) Position {
+	pos, _ := cfg.positions[file].lookup(path)
+	return pos
+}
+
+// Finds the file defining the path of a setting that is not part of a
+// command: the first file defining the exact path, else the closest parent
+// in the main file
+func (cfg *Config) find(path string) (string, Position) {
+	files := cfg.files
+	if len(files) == 0 {
+		files = []string{""}
+	}
+
+	normalized := mapKeyIndex.ReplaceAllString(path, ".$1")
+	for _, file := range files {
+		if pos, ok := cfg.positions[file][normalized]; ok {
+			return file, pos
+		}
+	}
+	if pos, ok := cfg.positions[files[0]].lookup(path); ok {
+		return files[0], pos
+	}
+	return "", Position{}
+}
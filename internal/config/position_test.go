@@ -0,0 +1,113 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions positions
+		want      map[string]Position
+	}{
+		{
+			name: "yaml",
+			positions: yamlDocumentPositions(t, `commands:
+  - name: api
+    command: ["./api", "--port"]
+    env:
+      PORT: "8080"
+keybindings:
+  sidebar:
+    ctrl-x: quit
+`),
+			want: map[string]Position{
+				"commands[0]":                   {Line: 2, Column: 5},
+				"commands[0].name":              {Line: 2, Column: 5},
+				"commands[0].command[1]":        {Line: 3, Column: 24},
+				"commands[0].env.PORT":          {Line: 5, Column: 7},
+				"commands[0].ready.tcp":         {Line: 2, Column: 5},
+				"keybindings.sidebar[ctrl-x]":   {Line: 8, Column: 5},
+				"keybindings.sidebar[ctrl-x].a": {Line: 8, Column: 5},
+				"clipboard":                     {Line: 1, Column: 1},
+			},
+		},
+		{
+			name: "json",
+			positions: jsonPositions([]byte(`{"commands": [
+  {"name": "api",
+    "command": ["./api", "--port"],
+    "env": {
+      "PORT": "8080"}}],
+"keybindings": {
+  "sidebar": {
+    "ctrl-x": "quit"}}}`)),
+			want: map[string]Position{
+				"commands[0]":                   {Line: 2, Column: 3},
+				"commands[0].name":              {Line: 2, Column: 4},
+				"commands[0].command[1]":        {Line: 3, Column: 26},
+				"commands[0].env.PORT":          {Line: 5, Column: 7},
+				"commands[0].ready.tcp":         {Line: 2, Column: 3},
+				"keybindings.sidebar[ctrl-x]":   {Line: 8, Column: 5},
+				"keybindings.sidebar[ctrl-x].a": {Line: 8, Column: 5},
+				"clipboard":                     {Line: 1, Column: 1},
+			},
+		},
+		{
+			name: "toml",
+			positions: tomlPositions([]byte(`# services
+[[commands]]
+name = "api"
+command = ["./api",
+  "--port"] # listen
+env = { PORT = "8080" }
+
+[[commands]]
+name = 'worker'
+description = """
+a [[queue]] worker"""
+[commands.ready]
+tcp = "localhost:8080"
+
+[keybindings.sidebar]
+"ctrl-x" = "quit"
+`)),
+			want: map[string]Position{
+				"commands[0]":                   {Line: 2, Column: 1},
+				"commands[0].name":              {Line: 3, Column: 1},
+				"commands[0].command[1]":        {Line: 5, Column: 3},
+				"commands[0].env.PORT":          {Line: 6, Column: 9},
+				"commands[0].ready.tcp":         {Line: 2, Column: 1},
+				"commands[1].name":              {Line: 9, Column: 1},
+				"commands[1].ready":             {Line: 12, Column: 1},
+				"commands[1].ready.tcp":         {Line: 13, Column: 1},
+				"commands[2]":                   {Line: 2, Column: 1},
+				"keybindings.sidebar[ctrl-x]":   {Line: 16, Column: 1},
+				"keybindings.sidebar[ctrl-x].a": {Line: 16, Column: 1},
+				"clipboard":                     {Line: 2, Column: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for path, want := range tt.want {
+				got, ok := tt.positions.lookup(path)
+				if !ok || got != want {
+					t.Errorf("lookup(%q) = %v, %v, want %v", path, got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func yamlDocumentPositions(t *testing.T, data string) positions {
+	t.Helper()
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &document); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	return yamlPositions(&document)
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// SelectProfiles keeps only the commands of the named profiles and the
+// commands they depend on, in the order of the configuration
+func (cfg *Config) SelectProfiles(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	selected := map[string]bool{}
+	var pending []string
+	for _, name := range names {
+		profile, ok := cfg.Profiles[name]
+		if !ok {
+			return fmt.Errorf("unknown profile '%s'", name)
+		}
+		pending = append(pending, profile...)
+	}
+
+	// Follow the dependencies of the selected commands
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if selected[name] {
+			continue
+		}
+		index := slices.IndexFunc(cfg.Commands, func(cmd Command) bool { return cmd.Name == name })
+		if index < 0 {
+			return fmt.Errorf("profile command '%s' does not exist", name)
+		}
+		selected[name] = true
+		pending = append(pending, cfg.Commands[index].DependsOn...)
+	}
+
+	cfg.Commands = slices.DeleteFunc(slices.Clone(cfg.Commands), func(cmd Command) bool {
+		return !selected[cmd.Name]
+	})
+	return nil
+}
+
+// validateProfiles checks that the profiles list existing commands
+func validateProfiles(profiles map[string][]string, names map[string]int) ValidationErrors {
+	var errors ValidationErrors
+
+	for _, profile := range slices.Sorted(maps.Keys(profiles)) {
+		commands := profiles[profile]
+		if len(commands) == 0 {
+			errors = append(errors, ValidationError{
+				Field:   "profiles." + profile,
+				Message: "must list at least one command",
+			})
+		}
+		for i, name := range commands {
+			if _, ok := names[name]; !ok {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("profiles.%s[%d]", profile, i),
+					Message: "unknown command",
+					Value:   name,
+				})
+			}
+		}
+	}
+
+	return errors
+}
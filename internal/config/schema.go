@@ -0,0 +1,183 @@
+package config
+
+import (
+	"embed"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// The sources of the configuration types, their comments describe the fields in the schema
+//
+//go:embed config.go clipboard.go keys.go
+var sources embed.FS
+
+// SchemaURL identifies the JSON Schema dialect of the generated schema
+const SchemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// Constraints of the fields that their Go types do not express, keyed by
+// type and field name
+var schemaConstraints = map[string]map[string]any{
+	"Command.Name":          {"pattern": validNamePattern.String()},
+	"Command.Command":       {"minItems": 1},
+	"LogFile.Format":        {"enum": []string{LogFormatRaw, LogFormatPlain}},
+	"RestartPolicy.Policy":  {"enum": []string{RestartNever, RestartOnFailure, RestartAlways}},
+	"Clipboard.Backend":     {"enum": clipboardBackends},
+	"Clipboard.Passthrough": {"enum": []string{PassthroughTmux, PassthroughScreen}},
+	"Keybindings.Sidebar":   {"additionalProperties": map[string]any{"type": "string", "enum": actions}},
+	"Keybindings.Focused":   {"additionalProperties": map[string]any{"type": "string", "enum": actions}},
+	"Keybindings.Prefixed":  {"additionalProperties": map[string]any{"type": "string", "enum": actions}},
+
+	// Templates and defaults are partial commands
+	"Config.Defaults":  {"$ref": "#/$defs/Template"},
+	"Config.Templates": {"additionalProperties": map[string]any{"$ref": "#/$defs/Template"}},
+}
+
+// Fields that must be set, by type. The fields of the configuration itself
+// are optional since they may come from included files.
+var schemaRequired = map[string][]string{
+	"Command": {"name", "command"},
+	"LogFile": {"path"},
+}
+
+// Schema returns a JSON Schema describing the configuration files, generated
+// from the configuration types
+func Schema() ([]byte, error) {
+	comments, err := parseComments()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &schemaGenerator{comments: comments, defs: map[string]any{}}
+	root := g.object(reflect.TypeOf(Config{}))
+	root["$schema"] = SchemaURL
+	root["title"] = "Multiplexer configuration"
+
+	// A template is a command whose fields are all optional
+	template := maps.Clone(g.defs["Command"].(map[string]any))
+	delete(template, "required")
+	template["description"] = "Fields inherited by the commands, any field of a command"
+	g.defs["Template"] = template
+	root["$defs"] = g.defs
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// Generates the schemas of the configuration types, struct types are defined
+// once under `$defs` and referenced by name
+type schemaGenerator struct {
+	comments map[string]string // doc comments by type name and by type and field name
+	defs     map[string]any
+}
+
+// Returns the schema of a value of the type
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // reserved while the type is generated
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// Returns the schema of the properties of a struct type
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := g.schema(field.Type)
+		if description := g.comments[t.Name()+"."+field.Name]; description != "" {
+			property["description"] = description
+		}
+		maps.Copy(property, schemaConstraints[t.Name()+"."+field.Name])
+		properties[name] = property
+	}
+
+	result := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if description := g.comments[t.Name()]; description != "" {
+		result["description"] = description
+	}
+	if required := schemaRequired[t.Name()]; len(required) > 0 {
+		result["required"] = required
+	}
+	return result
+}
+
+// Collects the doc comments of the configuration types and the trailing
+// comments of their fields
+func parseComments() (map[string]string, error) {
+	files, err := sources.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	comments := map[string]string{}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		data, err := sources.ReadFile(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parser.ParseFile(fset, file.Name(), data, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range parsed.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				comments[typeSpec.Name.Name] = commentText(gen.Doc)
+				for _, field := range structType.Fields.List {
+					for _, name := range field.Names {
+						comments[typeSpec.Name.Name+"."+name.Name] = commentText(field.Comment)
+					}
+				}
+			}
+		}
+	}
+	return comments, nil
+}
+
+// Returns the text of a comment as a single line
+func commentText(group *ast.CommentGroup) string {
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
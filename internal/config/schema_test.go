@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	data, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema() error = %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Description string `json:"description"`
+		} `json:"properties"`
+		Defs map[string]struct {
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				Ref         string   `json:"$ref"`
+				Description string   `json:"description"`
+				Enum        []string `json:"enum"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Schema() returned invalid JSON: %v", err)
+	}
+
+	if schema.Properties["commands"].Description == "" {
+		t.Error("Schema() has no description for commands")
+	}
+
+	command := schema.Defs["Command"]
+	if !slices.Equal(command.Required, []string{"name", "command"}) {
+		t.Errorf("Command requires %v, want name and command", command.Required)
+	}
+	if command.Properties["restart"].Ref != "#/$defs/RestartPolicy" {
+		t.Errorf("Command restart refers to %q", command.Properties["restart"].Ref)
+	}
+	if _, ok := command.Properties["source"]; ok {
+		t.Error("Schema() includes unexported fields")
+	}
+	if command.Properties["env_file"].Description != "Dotenv file with variables to set for the command, overridden by `env`" {
+		t.Errorf("Command env_file description = %q", command.Properties["env_file"].Description)
+	}
+
+	if len(schema.Defs["Template"].Required) > 0 {
+		t.Errorf("Template requires %v, want no fields", schema.Defs["Template"].Required)
+	}
+
+	policies := schema.Defs["RestartPolicy"].Properties["policy"].Enum
+	if !slices.Equal(policies, []string{RestartNever, RestartOnFailure, RestartAlways}) {
+		t.Errorf("RestartPolicy policy enum = %v", policies)
+	}
+}
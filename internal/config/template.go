@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Applies the defaults and the templates named by `extends` to the commands.
+// Fields set in a command take precedence over its template, which takes
+// precedence over the defaults. Env maps are merged.
+func (cfg *Config) applyTemplates() error {
+	var errors ValidationErrors
+
+	for i, cmd := range cfg.Commands {
+		base := Command{}
+		if cfg.Defaults != nil {
+			base = *cfg.Defaults
+		}
+
+		if cmd.Extends != "" {
+			template, err := cfg.template(cmd.Extends, nil)
+			if err != nil {
+				errors = append(errors, ValidationError{
+					File:    cmd.source.file,
+					Field:   cmd.source.path + ".extends",
+					Message: err.Error(),
+					Value:   cmd.Extends,
+				})
+				continue
+			}
+			base = template.inherit(base)
+		}
+
+		cfg.Commands[i] = cmd.inherit(base)
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+// Returns the named template with the templates it extends applied
+func (cfg *Config) template(name string, seen []string) (Command, error) {
+	if slices.Contains(seen, name) {
+		return Command{}, fmt.Errorf("template cycle through '%s'", name)
+	}
+
+	template, ok := cfg.Templates[name]
+	if !ok {
+		return Command{}, fmt.Errorf("unknown template '%s'", name)
+	}
+	if template.Extends == "" {
+		return template, nil
+	}
+
+	parent, err := cfg.template(template.Extends, append(seen, name))
+	if err != nil {
+		return Command{}, err
+	}
+	return template.inherit(parent), nil
+}
+
+// Returns the command with the fields it does not set taken from base. The
+// name is never inherited, env maps are merged with the command's values
+// taking precedence.
+func (c Command) inherit(base Command) Command {
+	c.inherited = maps.Clone(c.inherited)
+	if c.inherited == nil {
+		c.inherited = map[string]source{}
+	}
+
+	// Records that the field comes from base, or from where base got it
+	take := func(field string) {
+		if src, ok := base.inherited[field]; ok {
+			c.inherited[field] = src
+		} else {
+			c.inherited[field] = base.source
+		}
+	}
+
+	if len(c.Command) == 0 && len(base.Command) > 0 {
+		c.Command = base.Command
+		take("command")
+	}
+	if c.Title == "" && base.Title != "" {
+		c.Title = base.Title
+		take("title")
+	}
+	if c.CWD == "" && base.CWD != "" {
+		c.CWD = base.CWD
+		take("cwd")
+	}
+	if len(base.Env) > 0 {
+		if len(c.Env) == 0 {
+			take("env")
+		}
+		c.Env = mergeMaps(base.Env, c.Env)
+	}
+	if c.EnvFile == "" && base.EnvFile != "" {
+		c.EnvFile = base.EnvFile
+		take("env_file")
+	}
+	if !c.Shell && base.Shell {
+		c.Shell = true
+		take("shell")
+	}
+	if c.Autostart == nil && base.Autostart != nil {
+		c.Autostart = base.Autostart
+		take("autostart")
+	}
+	if c.Killable == nil && base.Killable != nil {
+		c.Killable = base.Killable
+		take("killable")
+	}
+	if len(c.DependsOn) == 0 && len(base.DependsOn) > 0 {
+		c.DependsOn = base.DependsOn
+		take("depends_on")
+	}
+	if c.Ready == nil && base.Ready != nil {
+		c.Ready = base.Ready
+		take("ready")
+	}
+	if c.Restart == nil && base.Restart != nil {
+		c.Restart = base.Restart
+		take("restart")
+	}
+	if c.Log == nil && base.Log != nil {
+		c.Log = base.Log
+		take("log")
+	}
+	if c.Scrollback == nil && base.Scrollback != nil {
+		c.Scrollback = base.Scrollback
+		take("scrollback")
+	}
+	if c.StopSignal == "" && base.StopSignal != "" {
+		c.StopSignal = base.StopSignal
+		take("stop_signal")
+	}
+	if c.StopTimeout == "" && base.StopTimeout != "" {
+		c.StopTimeout = base.StopTimeout
+		take("stop_timeout")
+	}
+	if len(c.StopCommand) == 0 && len(base.StopCommand) > 0 {
+		c.StopCommand = base.StopCommand
+		take("stop_command")
+	}
+
+	return c
+}
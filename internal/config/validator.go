@@ -2,10 +2,17 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/nodge/multiplexer/internal/process"
 )
 
 var (
@@ -15,16 +22,27 @@ var (
 
 // ValidationError represents a configuration validation error
 type ValidationError struct {
-	Field   string
-	Message string
-	Value   interface{}
+	File     string // configuration file the value came from, empty when unknown
+	Position        // where the value is defined in the file, zero when unknown
+	Field    string
+	Message  string
+	Value    interface{}
 }
 
 func (e ValidationError) Error() string {
+	location := ""
+	switch {
+	case e.Line > 0 && e.File != "":
+		location = fmt.Sprintf("%s:%d:%d: ", e.File, e.Line, e.Column)
+	case e.Line > 0:
+		location = fmt.Sprintf("line %d, column %d: ", e.Line, e.Column)
+	case e.File != "":
+		location = e.File + ": "
+	}
 	if e.Value != nil {
-		return fmt.Sprintf("field '%s': %s (value: %v)", e.Field, e.Message, e.Value)
+		return fmt.Sprintf("%sfield '%s': %s (value: %v)", location, e.Field, e.Message, e.Value)
 	}
-	return fmt.Sprintf("field '%s': %s", e.Field, e.Message)
+	return fmt.Sprintf("%sfield '%s': %s", location, e.Field, e.Message)
 }
 
 // ValidationErrors represents multiple validation errors
@@ -51,7 +69,7 @@ func (cfg *Config) ValidateStrict() error {
 			Field:   "commands",
 			Message: "must contain at least one command",
 		})
-		return errors
+		return append(slices.Clone(cfg.unknown), cfg.locate(errors)...)
 	}
 
 	names := make(map[string]int)
@@ -72,6 +90,20 @@ func (cfg *Config) ValidateStrict() error {
 		}
 	}
 
+	errors = append(errors, validateDependencies(cfg.Commands, names)...)
+	errors = append(errors, validateEnvKeys(cfg.Env, "env")...)
+	errors = append(errors, validateVariables(cfg)...)
+	errors = append(errors, validateProfiles(cfg.Profiles, names)...)
+
+	if cfg.Keybindings != nil {
+		errors = append(errors, validateKeybindings(cfg.Keybindings)...)
+	}
+
+	if cfg.Clipboard != nil {
+		errors = append(errors, validateClipboard(cfg.Clipboard)...)
+	}
+
+	errors = append(slices.Clone(cfg.unknown), cfg.locate(errors)...)
 	if len(errors) > 0 {
 		return errors
 	}
@@ -79,6 +111,77 @@ func (cfg *Config) ValidateStrict() error {
 	return nil
 }
 
+// validateDependencies checks that dependencies reference existing commands
+// and that the dependency graph has no cycles
+func validateDependencies(commands []Command, names map[string]int) ValidationErrors {
+	var errors ValidationErrors
+
+	for i, cmd := range commands {
+		seen := make(map[string]bool)
+		for j, dep := range cmd.DependsOn {
+			field := fmt.Sprintf("commands[%d].depends_on[%d]", i, j)
+			if _, exists := names[dep]; !exists {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Message: "references unknown command",
+					Value:   dep,
+				})
+			}
+			if seen[dep] {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Message: "duplicate dependency",
+					Value:   dep,
+				})
+			}
+			seen[dep] = true
+		}
+	}
+
+	// Depth-first search, a node found on the current path closes a cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dep := range commands[names[name]].DependsOn {
+			if _, exists := names[dep]; !exists {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := slices.Index(path, dep)
+				cycle := append(slices.Clone(path[start:]), dep)
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("commands[%d].depends_on", names[name]),
+					Message: "dependency cycle detected: " + strings.Join(cycle, " -> "),
+				})
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+	}
+
+	for _, cmd := range commands {
+		if _, exists := names[cmd.Name]; exists && state[cmd.Name] == unvisited {
+			visit(cmd.Name)
+		}
+	}
+
+	return errors
+}
+
 // validateCommandStrict performs strict validation of a single command
 func validateCommandStrict(cmd *Command, index int) ValidationErrors {
 	var errors ValidationErrors
@@ -127,27 +230,300 @@ func validateCommandStrict(cmd *Command, index int) ValidationErrors {
 	}
 
 	// Validate environment variables
-	for key, value := range cmd.Env {
+	errors = append(errors, validateEnvKeys(cmd.Env, prefix+".env")...)
+
+	// Validate readiness probe
+	if cmd.Ready != nil {
+		errors = append(errors, validateReadyProbe(cmd.Ready, prefix+".ready")...)
+	}
+
+	// Validate restart policy
+	if cmd.Restart != nil {
+		errors = append(errors, validateRestartPolicy(cmd.Restart, prefix+".restart")...)
+	}
+
+	// Validate log file
+	if cmd.Log != nil {
+		errors = append(errors, validateLogFile(cmd.Log, prefix+".log")...)
+	}
+
+	// Validate graceful stop
+	errors = append(errors, validateStop(cmd, prefix)...)
+
+	// Validate scrollback limits
+	if cmd.Scrollback != nil {
+		if cmd.Scrollback.Lines < 0 {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".scrollback.lines",
+				Message: "cannot be negative",
+				Value:   cmd.Scrollback.Lines,
+			})
+		}
+		if cmd.Scrollback.Spill < 0 {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".scrollback.spill",
+				Message: "cannot be negative",
+				Value:   cmd.Scrollback.Spill,
+			})
+		}
+	}
+
+	return errors
+}
+
+// validateStop checks the stop signal, timeout and command of a command
+func validateStop(cmd *Command, prefix string) ValidationErrors {
+	var errors ValidationErrors
+
+	if cmd.StopSignal != "" {
+		if _, err := process.ParseSignal(cmd.StopSignal); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".stop_signal",
+				Message: err.Error(),
+				Value:   cmd.StopSignal,
+			})
+		}
+	}
+
+	errors = append(errors, validateDuration(cmd.StopTimeout, prefix+".stop_timeout")...)
+
+	if len(cmd.StopCommand) > 0 && cmd.StopCommand[0] == "" {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".stop_command[0]",
+			Message: "cannot be empty string",
+		})
+	}
+
+	return errors
+}
+
+// validateEnvKeys checks the keys of environment variables, empty values are allowed
+func validateEnvKeys(env map[string]string, field string) ValidationErrors {
+	var errors ValidationErrors
+
+	for key := range env {
 		if key == "" {
 			errors = append(errors, ValidationError{
-				Field:   prefix + ".env",
+				Field:   field,
 				Message: "environment variable key cannot be empty",
 			})
 		}
 		if strings.Contains(key, "=") {
 			errors = append(errors, ValidationError{
-				Field:   prefix + ".env",
+				Field:   field,
 				Message: "environment variable key cannot contain '=' character",
 				Value:   key,
 			})
 		}
-		// Note: empty values are allowed for env vars
-		_ = value
 	}
 
 	return errors
 }
 
+// validateLogFile checks the log file path, format and rotation limits
+func validateLogFile(log *LogFile, prefix string) ValidationErrors {
+	var errors ValidationErrors
+
+	if log.Path == "" {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".path",
+			Message: "cannot be empty",
+		})
+	}
+
+	switch log.Format {
+	case "", LogFormatRaw, LogFormatPlain:
+	default:
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".format",
+			Message: fmt.Sprintf("must be one of %s or %s", LogFormatRaw, LogFormatPlain),
+			Value:   log.Format,
+		})
+	}
+
+	if _, err := parseSize(log.MaxSize); err != nil {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".max_size",
+			Message: "must be a non-negative size such as 512KB, 10MB or 1GB",
+			Value:   log.MaxSize,
+		})
+	}
+
+	errors = append(errors, validateDuration(log.MaxAge, prefix+".max_age")...)
+
+	if log.MaxFiles < 0 {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".max_files",
+			Message: "cannot be negative",
+			Value:   log.MaxFiles,
+		})
+	}
+
+	return errors
+}
+
+// validateRestartPolicy checks the restart policy name, retry limit and delays
+func validateRestartPolicy(restart *RestartPolicy, prefix string) ValidationErrors {
+	var errors ValidationErrors
+
+	switch restart.Policy {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".policy",
+			Message: fmt.Sprintf("must be one of %s, %s or %s", RestartNever, RestartOnFailure, RestartAlways),
+			Value:   restart.Policy,
+		})
+	}
+
+	if restart.MaxRetries < 0 {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".max_retries",
+			Message: "cannot be negative",
+			Value:   restart.MaxRetries,
+		})
+	}
+
+	errors = append(errors, validateDuration(restart.Backoff, prefix+".backoff")...)
+	errors = append(errors, validateDuration(restart.MaxBackoff, prefix+".max_backoff")...)
+	errors = append(errors, validateDuration(restart.ResetAfter, prefix+".reset_after")...)
+
+	return errors
+}
+
+// validateKeybindings checks the key chords and actions of every binding mode
+func validateKeybindings(keys *Keybindings) ValidationErrors {
+	var errors ValidationErrors
+
+	if keys.Prefix != "" {
+		if _, err := NormalizeChord(keys.Prefix); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "keybindings.prefix",
+				Message: err.Error(),
+				Value:   keys.Prefix,
+			})
+		}
+	}
+
+	modes := []struct {
+		name     string
+		bindings map[string]string
+	}{
+		{"sidebar", keys.Sidebar},
+		{"focused", keys.Focused},
+		{"prefixed", keys.Prefixed},
+	}
+	for _, mode := range modes {
+		// Sorted for a stable error order
+		chords := slices.Sorted(maps.Keys(mode.bindings))
+		for _, chord := range chords {
+			field := fmt.Sprintf("keybindings.%s[%s]", mode.name, chord)
+			if _, err := NormalizeChord(chord); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Message: err.Error(),
+				})
+			}
+			if action := mode.bindings[chord]; !slices.Contains(actions, action) {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Message: "must be one of " + strings.Join(actions, ", "),
+					Value:   action,
+				})
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateReadyProbe checks that exactly one probe type is set and its settings are valid
+func validateReadyProbe(probe *ReadyProbe, prefix string) ValidationErrors {
+	var errors ValidationErrors
+
+	kinds := 0
+	for _, set := range []bool{probe.TCP != "", probe.HTTP != "", probe.Log != "", len(probe.Exec) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		errors = append(errors, ValidationError{
+			Field:   prefix,
+			Message: "must set exactly one of tcp, http, log or exec",
+		})
+	}
+
+	if probe.TCP != "" {
+		if _, _, err := net.SplitHostPort(probe.TCP); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".tcp",
+				Message: "must be a host:port address",
+				Value:   probe.TCP,
+			})
+		}
+	}
+
+	if probe.HTTP != "" {
+		if u, err := url.Parse(probe.HTTP); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".http",
+				Message: "must be an absolute http or https URL",
+				Value:   probe.HTTP,
+			})
+		}
+	}
+
+	if probe.Status != 0 && (probe.Status < 100 || probe.Status > 599) {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".status",
+			Message: "must be a valid HTTP status code",
+			Value:   probe.Status,
+		})
+	}
+
+	if probe.Log != "" {
+		if _, err := regexp.Compile(probe.Log); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   prefix + ".log",
+				Message: "invalid regular expression: " + err.Error(),
+				Value:   probe.Log,
+			})
+		}
+	}
+
+	for i, part := range probe.Exec {
+		if part == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("%s.exec[%d]", prefix, i),
+				Message: "cannot be empty string",
+			})
+		}
+	}
+
+	errors = append(errors, validateDuration(probe.Interval, prefix+".interval")...)
+	errors = append(errors, validateDuration(probe.Timeout, prefix+".timeout")...)
+
+	return errors
+}
+
+// validateDuration checks that an optional duration string is parseable and not negative
+func validateDuration(value string, field string) ValidationErrors {
+	if value == "" {
+		return nil
+	}
+
+	if d, err := time.ParseDuration(value); err != nil || d < 0 {
+		return ValidationErrors{{
+			Field:   field,
+			Message: "must be a non-negative duration such as 500ms, 5s or 1m",
+			Value:   value,
+		}}
+	}
+
+	return nil
+}
+
 // validateDirectory checks if a directory path is valid
 func validateDirectory(path string) error {
 	if path == "" {
@@ -199,10 +575,34 @@ func (cfg *Config) ValidateAtRuntime(baseCWD string) error {
 				Value:   actualCWD,
 			})
 		}
+
+		// Validate the directory of the log file
+		if cmd.Log != nil && cmd.Log.Path != "" {
+			dir := filepath.Dir(cmd.Log.GetPath(actualCWD))
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				errors = append(errors, ValidationError{
+					Field:   prefix + ".log.path",
+					Message: "resolved directory does not exist",
+					Value:   dir,
+				})
+			}
+		}
+	}
+
+	// Validate the directory of the clipboard file
+	if cfg.Clipboard != nil && cfg.Clipboard.GetBackend() == ClipboardFile && cfg.Clipboard.Path != "" {
+		dir := filepath.Dir(cfg.Clipboard.GetPath(baseCWD))
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			errors = append(errors, ValidationError{
+				Field:   "clipboard.path",
+				Message: "resolved directory does not exist",
+				Value:   dir,
+			})
+		}
 	}
 
 	if len(errors) > 0 {
-		return errors
+		return cfg.locate(errors)
 	}
 
 	return nil
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfig_ValidateStrict_Dependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []Command
+		wantErr  string
+	}{
+		{
+			name: "valid dependencies",
+			commands: []Command{
+				{Name: "db", Command: []string{"postgres"}},
+				{Name: "cache", Command: []string{"redis-server"}},
+				{Name: "api", Command: []string{"go", "run", "."}, DependsOn: []string{"db", "cache"}},
+			},
+		},
+		{
+			name: "unknown dependency",
+			commands: []Command{
+				{Name: "api", Command: []string{"go", "run", "."}, DependsOn: []string{"db"}},
+			},
+			wantErr: "references unknown command",
+		},
+		{
+			name: "duplicate dependency",
+			commands: []Command{
+				{Name: "db", Command: []string{"postgres"}},
+				{Name: "api", Command: []string{"go", "run", "."}, DependsOn: []string{"db", "db"}},
+			},
+			wantErr: "duplicate dependency",
+		},
+		{
+			name: "self dependency",
+			commands: []Command{
+				{Name: "api", Command: []string{"go", "run", "."}, DependsOn: []string{"api"}},
+			},
+			wantErr: "dependency cycle detected: api -> api",
+		},
+		{
+			name: "indirect cycle",
+			commands: []Command{
+				{Name: "a", Command: []string{"echo"}, DependsOn: []string{"b"}},
+				{Name: "b", Command: []string{"echo"}, DependsOn: []string{"c"}},
+				{Name: "c", Command: []string{"echo"}, DependsOn: []string{"a"}},
+			},
+			wantErr: "dependency cycle detected: a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Commands: tt.commands}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var validationErrors ValidationErrors
+			if !errors.As(err, &validationErrors) {
+				t.Fatalf("ValidateStrict() error = %v, want ValidationErrors", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_ReadyProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		ready   ReadyProbe
+		wantErr string
+	}{
+		{
+			name:  "tcp probe",
+			ready: ReadyProbe{TCP: "localhost:5432", Interval: "500ms", Timeout: "30s"},
+		},
+		{
+			name:  "http probe",
+			ready: ReadyProbe{HTTP: "http://localhost:8080/health", Status: 204},
+		},
+		{
+			name:  "log probe",
+			ready: ReadyProbe{Log: `listening on :\d+`},
+		},
+		{
+			name:  "exec probe",
+			ready: ReadyProbe{Exec: []string{"pg_isready"}},
+		},
+		{
+			name:    "no probe type",
+			ready:   ReadyProbe{Interval: "1s"},
+			wantErr: "must set exactly one of tcp, http, log or exec",
+		},
+		{
+			name:    "several probe types",
+			ready:   ReadyProbe{TCP: "localhost:5432", Log: "ready"},
+			wantErr: "must set exactly one of tcp, http, log or exec",
+		},
+		{
+			name:    "invalid tcp address",
+			ready:   ReadyProbe{TCP: "localhost"},
+			wantErr: "must be a host:port address",
+		},
+		{
+			name:    "relative http url",
+			ready:   ReadyProbe{HTTP: "/health"},
+			wantErr: "must be an absolute http or https URL",
+		},
+		{
+			name:    "invalid status",
+			ready:   ReadyProbe{HTTP: "http://localhost", Status: 42},
+			wantErr: "must be a valid HTTP status code",
+		},
+		{
+			name:    "invalid log pattern",
+			ready:   ReadyProbe{Log: "("},
+			wantErr: "invalid regular expression",
+		},
+		{
+			name:    "invalid interval",
+			ready:   ReadyProbe{TCP: "localhost:5432", Interval: "soon"},
+			wantErr: "ready.interval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready := tt.ready
+			cfg := Config{Commands: []Command{
+				{Name: "db", Command: []string{"postgres"}, Ready: &ready},
+			}}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_RestartPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		restart RestartPolicy
+		wantErr string
+	}{
+		{
+			name:    "on-failure with limits",
+			restart: RestartPolicy{Policy: RestartOnFailure, MaxRetries: 5, Backoff: "500ms", MaxBackoff: "10s", ResetAfter: "2m"},
+		},
+		{
+			name:    "always",
+			restart: RestartPolicy{Policy: RestartAlways},
+		},
+		{
+			name:    "unknown policy",
+			restart: RestartPolicy{Policy: "sometimes"},
+			wantErr: "must be one of never, on-failure or always",
+		},
+		{
+			name:    "negative retries",
+			restart: RestartPolicy{Policy: RestartAlways, MaxRetries: -1},
+			wantErr: "cannot be negative",
+		},
+		{
+			name:    "invalid backoff",
+			restart: RestartPolicy{Policy: RestartAlways, Backoff: "-1s"},
+			wantErr: "restart.backoff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restart := tt.restart
+			cfg := Config{Commands: []Command{
+				{Name: "api", Command: []string{"go", "run", "."}, Restart: &restart},
+			}}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_Stop(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     Command
+		wantErr string
+	}{
+		{
+			name: "signal, timeout and command",
+			cmd:  Command{StopSignal: "SIGINT", StopTimeout: "10s", StopCommand: []string{"docker", "compose", "down"}},
+		},
+		{
+			name: "signal without prefix",
+			cmd:  Command{StopSignal: "quit"},
+		},
+		{
+			name:    "unknown signal",
+			cmd:     Command{StopSignal: "SIGSTOP"},
+			wantErr: "unknown signal 'SIGSTOP'",
+		},
+		{
+			name:    "invalid timeout",
+			cmd:     Command{StopTimeout: "later"},
+			wantErr: "stop_timeout",
+		},
+		{
+			name:    "empty stop command",
+			cmd:     Command{StopCommand: []string{"", "down"}},
+			wantErr: "stop_command[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.cmd
+			cmd.Name, cmd.Command = "compose", []string{"docker", "compose", "up"}
+			cfg := Config{Commands: []Command{cmd}}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_Keybindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    Keybindings
+		wantErr string
+	}{
+		{
+			name: "valid bindings",
+			keys: Keybindings{
+				Prefix:   "ctrl-b",
+				Sidebar:  map[string]string{"q": ActionQuit, "ctrl-c": ActionNone},
+				Focused:  map[string]string{"ctrl-z": ActionNone},
+				Prefixed: map[string]string{"z": ActionSidebar, "shift-tab": ActionNextSplit},
+			},
+		},
+		{
+			name:    "invalid prefix",
+			keys:    Keybindings{Prefix: "hyper-a"},
+			wantErr: "keybindings.prefix",
+		},
+		{
+			name:    "unknown key",
+			keys:    Keybindings{Sidebar: map[string]string{"ctrl-pause": ActionQuit}},
+			wantErr: "keybindings.sidebar[ctrl-pause]",
+		},
+		{
+			name:    "unknown action",
+			keys:    Keybindings{Focused: map[string]string{"ctrl-q": "explode"}},
+			wantErr: "must be one of none, up, down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := tt.keys
+			cfg := Config{
+				Commands:    []Command{{Name: "api", Command: []string{"go", "run", "."}}},
+				Keybindings: &keys,
+			}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				if err := cfg.Validate(); err != nil {
+					t.Fatalf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+			if err := cfg.Validate(); err == nil {
+				t.Error("Validate() expected error")
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_LogFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     LogFile
+		wantErr string
+	}{
+		{
+			name: "plain with rotation",
+			log:  LogFile{Path: "api.log", Format: LogFormatPlain, Timestamps: true, MaxSize: "10MB", MaxAge: "24h", MaxFiles: 3},
+		},
+		{
+			name:    "missing path",
+			log:     LogFile{Format: LogFormatRaw},
+			wantErr: "log.path",
+		},
+		{
+			name:    "unknown format",
+			log:     LogFile{Path: "api.log", Format: "html"},
+			wantErr: "must be one of raw or plain",
+		},
+		{
+			name:    "invalid size",
+			log:     LogFile{Path: "api.log", MaxSize: "ten megabytes"},
+			wantErr: "log.max_size",
+		},
+		{
+			name:    "invalid age",
+			log:     LogFile{Path: "api.log", MaxAge: "1 day"},
+			wantErr: "log.max_age",
+		},
+		{
+			name:    "negative files",
+			log:     LogFile{Path: "api.log", MaxFiles: -1},
+			wantErr: "log.max_files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := tt.log
+			cfg := Config{Commands: []Command{
+				{Name: "api", Command: []string{"go", "run", "."}, Log: &log},
+			}}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_Variables(t *testing.T) {
+	t.Setenv("MULTIPLEXER_TEST_SET", "1")
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name: "resolved variables",
+			cfg: Config{
+				Env: map[string]string{"PORT": "8080"},
+				Commands: []Command{
+					{Name: "api", Command: []string{"serve", "${PORT}", "${MULTIPLEXER_TEST_SET}", "${MISSING:-x}"}},
+				},
+			},
+		},
+		{
+			name: "unresolved in command",
+			cfg: Config{Commands: []Command{
+				{Name: "api", Command: []string{"serve", "${MULTIPLEXER_TEST_UNSET}"}},
+			}},
+			wantErr: "commands[0].command[1]': variable 'MULTIPLEXER_TEST_UNSET' is not set",
+		},
+		{
+			name: "unresolved in env",
+			cfg: Config{Commands: []Command{
+				{Name: "api", Command: []string{"serve"}, Env: map[string]string{"URL": "${MULTIPLEXER_TEST_UNSET}"}},
+			}},
+			wantErr: "commands[0].env.URL",
+		},
+		{
+			name: "missing env file",
+			cfg: Config{EnvFile: "missing.env", Commands: []Command{
+				{Name: "api", Command: []string{"serve"}},
+			}},
+			wantErr: "field 'env_file'",
+		},
+		{
+			name: "invalid shared env key",
+			cfg: Config{Env: map[string]string{"A=B": "c"}, Commands: []Command{
+				{Name: "api", Command: []string{"serve"}},
+			}},
+			wantErr: "cannot contain '=' character",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStrict_Clipboard(t *testing.T) {
+	tests := []struct {
+		name      string
+		clipboard Clipboard
+		wantErr   string
+	}{
+		{
+			name:      "osc52 through tmux",
+			clipboard: Clipboard{Backend: ClipboardOSC52, MaxSize: "64KB", Passthrough: PassthroughTmux},
+		},
+		{
+			name:      "custom command",
+			clipboard: Clipboard{Backend: ClipboardCommand, Command: []string{"clip.exe"}},
+		},
+		{
+			name:      "unknown backend",
+			clipboard: Clipboard{Backend: "pasteboard"},
+			wantErr:   "must be one of auto, osc52",
+		},
+		{
+			name:      "file without path",
+			clipboard: Clipboard{Backend: ClipboardFile},
+			wantErr:   "clipboard.path",
+		},
+		{
+			name:      "command without command",
+			clipboard: Clipboard{Backend: ClipboardCommand},
+			wantErr:   "clipboard.command",
+		},
+		{
+			name:      "invalid size",
+			clipboard: Clipboard{MaxSize: "a lot"},
+			wantErr:   "clipboard.max_size",
+		},
+		{
+			name:      "unknown passthrough",
+			clipboard: Clipboard{Passthrough: "zellij"},
+			wantErr:   "must be tmux or screen",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clipboard := tt.clipboard
+			cfg := Config{
+				Commands:  []Command{{Name: "api", Command: []string{"go", "run", "."}}},
+				Clipboard: &clipboard,
+			}
+			err := cfg.ValidateStrict()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrict() unexpected error = %v", err)
+				}
+				if err := cfg.Validate(); err != nil {
+					t.Fatalf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStrict() error = %v, want %q", err, tt.wantErr)
+			}
+			if err := cfg.Validate(); err == nil {
+				t.Error("Validate() expected error")
+			}
+		})
+	}
+}
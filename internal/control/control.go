@@ -0,0 +1,89 @@
+// Package control defines the protocol of the multiplexer control socket.
+//
+// Clients connect to a Unix-domain socket and exchange newline-delimited
+// JSON messages: every Request is answered with exactly one Response.
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/nodge/multiplexer/internal/socket"
+)
+
+// SocketEnv is the environment variable holding the control socket path of
+// the running multiplexer, it is inherited by the processes in its panes
+const SocketEnv = "MULTIPLEXER_SOCKET"
+
+// Request actions
+const (
+	ActionList    = "list"    // list all panes
+	ActionStart   = "start"   // start a stopped pane
+	ActionStop    = "stop"    // stop a running pane
+	ActionRestart = "restart" // stop the pane if it is running and start it again
+	ActionSend    = "send"    // write input to a running pane
+	ActionDump    = "dump"    // return the screen, and optionally the scrollback, of a pane
+)
+
+// Request represents a single command sent to the control socket
+type Request struct {
+	Action     string `json:"action"`
+	Pane       string `json:"pane,omitempty"`       // Name of the target pane
+	Input      string `json:"input,omitempty"`      // Raw input for the send action
+	Scrollback bool   `json:"scrollback,omitempty"` // Include the scrollback in the dump action
+}
+
+// Response represents the answer to a Request
+type Response struct {
+	Error  string     `json:"error,omitempty"`
+	Panes  []PaneInfo `json:"panes,omitempty"`  // Result of the list action
+	Output string     `json:"output,omitempty"` // Result of the dump action
+}
+
+// PaneInfo describes the state of a single pane
+type PaneInfo struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	State    string `json:"state"`               // running, waiting, restarting, exited or stopped
+	Ready    string `json:"ready,omitempty"`     // starting, ready or failed when a readiness probe is set
+	PID      int    `json:"pid,omitempty"`       // Process ID while running
+	ExitCode *int   `json:"exit_code,omitempty"` // Exit code of the last run
+	Signal   string `json:"signal,omitempty"`    // Signal that terminated the last run
+	Exit     string `json:"exit,omitempty"`      // Description of how the last run ended
+}
+
+// DefaultSocketPath returns the socket path used when none is configured
+func DefaultSocketPath() string {
+	return filepath.Join(socket.Dir(), "multiplexer-"+strconv.Itoa(os.Getuid())+".sock")
+}
+
+// Call sends the request to the control socket and waits for the response.
+// An error reported by the multiplexer is returned as an error.
+func Call(path string, req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to control socket '%s': %w", path, err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return &resp, fmt.Errorf("%s", resp.Error)
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,66 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// serve answers every request on the socket with the response returned by handle
+func serve(t *testing.T, handle func(Request) Response) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "control.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			var req Request
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err == nil {
+				json.NewEncoder(conn).Encode(handle(req))
+			}
+			conn.Close()
+		}
+	}()
+
+	return path
+}
+
+func TestCall(t *testing.T) {
+	path := serve(t, func(req Request) Response {
+		if req.Action != ActionList {
+			return Response{Error: "unknown action '" + req.Action + "'"}
+		}
+		return Response{Panes: []PaneInfo{{Name: "api", State: "running", PID: 42}}}
+	})
+
+	resp, err := Call(path, Request{Action: ActionList})
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if len(resp.Panes) != 1 || resp.Panes[0].Name != "api" || resp.Panes[0].PID != 42 {
+		t.Errorf("Call() panes = %+v, want the api pane", resp.Panes)
+	}
+
+	_, err = Call(path, Request{Action: "explode"})
+	if err == nil || err.Error() != "unknown action 'explode'" {
+		t.Errorf("Call() error = %v, want the error reported by the server", err)
+	}
+}
+
+func TestCall_NoServer(t *testing.T) {
+	_, err := Call(filepath.Join(t.TempDir(), "missing.sock"), Request{Action: ActionList})
+	if err == nil {
+		t.Error("Expected error for missing socket, got nil")
+	}
+}
@@ -0,0 +1,243 @@
+// Package logfile writes the output of pane processes to files on disk.
+//
+// A Writer appends to a file, optionally strips the terminal escape
+// sequences and prefixes every line with a timestamp. The file is rotated
+// once it exceeds a size or an age: `app.log` becomes `app.log.1`, the
+// previous `app.log.1` becomes `app.log.2` and so on, the oldest file is
+// removed.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Format of the timestamps prefixed to the lines
+const timestampFormat = "2006-01-02T15:04:05.000Z07:00 "
+
+// Options describes the log file and its rotation
+type Options struct {
+	Path       string
+	Plain      bool          // strip terminal escape sequences and control characters
+	Timestamps bool          // prefix every line with the time it was written
+	MaxSize    int64         // size in bytes after which the file is rotated, zero means no limit
+	MaxAge     time.Duration // age after which the file is rotated, zero means no limit
+	MaxFiles   int           // number of rotated files to keep
+}
+
+// Writer appends the output of a process to a log file, it is safe for concurrent use
+type Writer struct {
+	opts Options
+	now  func() time.Time
+
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	opened    time.Time
+	lineStart bool
+	strip     stripper
+}
+
+// Open opens the log file for appending, creating it if needed. An existing
+// file older than the maximum age is rotated first.
+func Open(opts Options) (*Writer, error) {
+	w := &Writer{opts: opts, now: time.Now, lineStart: true}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	if opts.MaxAge > 0 && w.size > 0 {
+		if info, err := w.file.Stat(); err == nil && w.now().Sub(info.ModTime()) >= opts.MaxAge {
+			if err := w.rotate(); err != nil {
+				w.file.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// Write appends the output to the file, rotating it when it grows too large or too old
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	out := w.format(p)
+	if len(out) == 0 {
+		return len(p), nil
+	}
+
+	if w.size > 0 && (w.opts.MaxSize > 0 && w.size+int64(len(out)) > w.opts.MaxSize ||
+		w.opts.MaxAge > 0 && w.now().Sub(w.opened) >= w.opts.MaxAge) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(out)
+	w.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the log file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Applies the stripping and the timestamps to the output
+func (w *Writer) format(p []byte) []byte {
+	if w.opts.Plain {
+		p = w.strip.strip(p)
+	}
+	if !w.opts.Timestamps {
+		return p
+	}
+
+	timestamp := w.now().Format(timestampFormat)
+	out := make([]byte, 0, len(p)+len(timestamp))
+	for _, b := range p {
+		if w.lineStart {
+			out = append(out, timestamp...)
+			w.lineStart = false
+		}
+		out = append(out, b)
+		if b == '\n' {
+			w.lineStart = true
+		}
+	}
+	return out
+}
+
+// Opens the file at the configured path for appending
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.opened = w.now()
+	return nil
+}
+
+// Shifts the rotated files by one, moves the current file to the first
+// position and starts a new one
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	maxFiles := max(w.opts.MaxFiles, 1)
+	_ = os.Remove(w.rotated(maxFiles))
+	for i := maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(w.rotated(i), w.rotated(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(w.opts.Path, w.rotated(1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	return w.open()
+}
+
+// Returns the path of the n-th rotated file
+func (w *Writer) rotated(n int) string {
+	return w.opts.Path + "." + strconv.Itoa(n)
+}
+
+// States of the escape sequence stripper
+const (
+	stripGround       = iota
+	stripEscape       // after ESC
+	stripIntermediate // ESC followed by intermediate bytes
+	stripCSI          // control sequence, ends with a final byte
+	stripString       // OSC, DCS, SOS, PM or APC string, ends with BEL or ST
+	stripStringEscape // ESC inside a string, possibly the start of ST
+)
+
+// stripper removes escape sequences and control characters from the output,
+// keeping newlines and tabs. The state carries over between writes, so
+// sequences split across reads are removed too.
+type stripper struct {
+	state int
+}
+
+// Returns the printable part of the output
+func (s *stripper) strip(p []byte) []byte {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch s.state {
+		case stripGround:
+			switch {
+			case b == 0x1b:
+				s.state = stripEscape
+			case b == '\n' || b == '\t' || b >= 0x20 && b != 0x7f:
+				out = append(out, b)
+			}
+
+		case stripEscape:
+			switch {
+			case b == '[':
+				s.state = stripCSI
+			case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+				s.state = stripString
+			case b >= 0x20 && b <= 0x2f:
+				s.state = stripIntermediate
+			default:
+				s.state = stripGround
+			}
+
+		case stripIntermediate:
+			if b < 0x20 || b > 0x2f {
+				s.state = stripGround
+			}
+
+		case stripCSI:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = stripGround
+			}
+
+		case stripString:
+			switch b {
+			case 0x07:
+				s.state = stripGround
+			case 0x1b:
+				s.state = stripStringEscape
+			}
+
+		case stripStringEscape:
+			s.state = stripString
+			if b == '\\' {
+				s.state = stripGround
+			}
+		}
+	}
+	return out
+}
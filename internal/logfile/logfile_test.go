@@ -0,0 +1,153 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStripper(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "plain text",
+			chunks: []string{"hello\tworld\n"},
+			want:   "hello\tworld\n",
+		},
+		{
+			name:   "colors and carriage returns",
+			chunks: []string{"\x1b[1;31merror\x1b[0m: failed\r\n"},
+			want:   "error: failed\n",
+		},
+		{
+			name:   "window title",
+			chunks: []string{"\x1b]0;title\x07ok\x1b]2;other\x1b\\\n"},
+			want:   "ok\n",
+		},
+		{
+			name:   "charset and keypad sequences",
+			chunks: []string{"\x1b(Bab\x1b=c\n"},
+			want:   "abc\n",
+		},
+		{
+			name:   "sequence split across writes",
+			chunks: []string{"a\x1b", "[3", "2mb\x1b]8;;http://x", "\x1b", "\\c"},
+			want:   "abc",
+		},
+		{
+			name:   "utf-8",
+			chunks: []string{"\x1b[33mgrüße ✓\x1b[m\n"},
+			want:   "grüße ✓\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s stripper
+			got := ""
+			for _, chunk := range tt.chunks {
+				got += string(s.strip([]byte(chunk)))
+			}
+			if got != tt.want {
+				t.Errorf("strip() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_Timestamps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := Open(Options{Path: path, Plain: true, Timestamps: true})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	w.now = func() time.Time { return time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC) }
+
+	for _, chunk := range []string{"first ", "line\r\n\x1b[32msecond\x1b[0m", " line\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	w.Close()
+
+	want := "2024-05-01T12:30:00.000Z first line\n2024-05-01T12:30:00.000Z second line\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestWriter_RotateSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := Open(Options{Path: path, MaxSize: 10, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for _, chunk := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	w.Close()
+
+	files := map[string]string{
+		path:        "four\nfive\n",
+		path + ".1": "three\n",
+		path + ".2": "one\ntwo\n",
+	}
+	for file, want := range files {
+		if got := readFile(t, file); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("app.log.3 exists, want at most 2 rotated files")
+	}
+}
+
+func TestWriter_RotateAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(path, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	// The existing file is too old and rotated when opened
+	w, err := Open(Options{Path: path, MaxAge: time.Hour, MaxFiles: 3})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	w.Write([]byte("new\n"))
+
+	now := time.Now()
+	w.now = func() time.Time { return now.Add(2 * time.Hour) }
+	w.Write([]byte("later\n"))
+	w.Close()
+
+	files := map[string]string{
+		path:        "later\n",
+		path + ".1": "new\n",
+		path + ".2": "old\n",
+	}
+	for file, want := range files {
+		if got := readFile(t, file); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, want)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return string(data)
+}
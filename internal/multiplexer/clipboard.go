@@ -0,0 +1,48 @@
+package multiplexer
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/clipboard"
+)
+
+// EventClipboard is a custom event used to replace the clipboard backend
+type EventClipboard struct {
+	tcell.EventTime
+	Options clipboard.Options
+}
+
+// SetClipboard posts an event that replaces the clipboard backend, the
+// terminal of the multiplexer receives the OSC 52 sequences
+func (s *Multiplexer) SetClipboard(opts clipboard.Options) {
+	s.ui.screen.PostEvent(&EventClipboard{Options: opts})
+}
+
+// Replaces the clipboard backend, keeps the previous one if the options are invalid
+func (s *Multiplexer) setClipboard(opts clipboard.Options) {
+	opts.Terminal = terminalWriter{screen: s.ui.screen}
+	c, err := clipboard.New(opts)
+	if err != nil {
+		slog.Error("invalid clipboard", "err", err)
+		return
+	}
+	s.clipboard = c
+}
+
+// terminalWriter writes escape sequences to the terminal of the screen, the
+// client's terminal in a session. It must be used from the main event loop,
+// which also draws the screen, so the sequences do not interleave with the
+// output of tcell.
+type terminalWriter struct {
+	screen tcell.Screen
+}
+
+func (w terminalWriter) Write(p []byte) (int, error) {
+	tty, ok := w.screen.Tty()
+	if !ok {
+		return 0, errors.New("the screen has no terminal to write to")
+	}
+	return tty.Write(p)
+}
@@ -0,0 +1,215 @@
+package multiplexer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/control"
+	"github.com/nodge/multiplexer/internal/socket"
+)
+
+// EventControl carries a control socket request into the main event loop
+type EventControl struct {
+	tcell.EventTime
+	Request control.Request
+
+	reply chan control.Response
+}
+
+// Listen starts the control socket server on the given Unix-domain socket path.
+// Requests are handled by the main event loop, the server stops when the multiplexer exits.
+func (s *Multiplexer) Listen(path string) error {
+	// A socket file left behind by a crashed multiplexer is replaced, a live one is an error
+	listener, err := socket.Listen(path)
+	if errors.Is(err, socket.ErrInUse) {
+		return fmt.Errorf("control socket '%s' is already in use", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket '%s': %w", path, err)
+	}
+
+	// Processes started in the panes can find the socket through the environment
+	os.Setenv(control.SocketEnv, path)
+	s.listener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					slog.Error("control socket accept failed", "err", err)
+				}
+				return
+			}
+			go s.serveControl(conn)
+		}
+	}()
+
+	return nil
+}
+
+// Reads requests from a control connection and writes back the responses
+func (s *Multiplexer) serveControl(conn net.Conn) {
+	defer conn.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	encoder := json.NewEncoder(conn)
+
+	for {
+		var req control.Request
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+
+		evt := &EventControl{Request: req, reply: make(chan control.Response, 1)}
+		if err := s.ui.screen.PostEvent(evt); err != nil {
+			encoder.Encode(control.Response{Error: "multiplexer is busy: " + err.Error()})
+			continue
+		}
+
+		var resp control.Response
+		select {
+		case resp = <-evt.reply:
+		case <-s.ctx.Done():
+			return
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// Executes a control request, must be called from the main event loop
+func (s *Multiplexer) handleControl(req control.Request) control.Response {
+	if req.Action == control.ActionList {
+		panes := make([]control.PaneInfo, 0, len(s.ui.panes))
+		for _, p := range s.ui.panes {
+			panes = append(panes, p.info())
+		}
+		return control.Response{Panes: panes}
+	}
+
+	switch req.Action {
+	case control.ActionStart, control.ActionStop, control.ActionRestart, control.ActionSend, control.ActionDump:
+	default:
+		return control.Response{Error: fmt.Sprintf("unknown action '%s'", req.Action)}
+	}
+
+	p := s.findPane(req.Pane)
+	if p == nil {
+		return control.Response{Error: fmt.Sprintf("pane '%s' not found", req.Pane)}
+	}
+
+	switch req.Action {
+	case control.ActionStart:
+		if !p.dead {
+			return control.Response{Error: fmt.Sprintf("pane '%s' is already running", p.key)}
+		}
+		p.restarts = 0
+		s.startPane(p)
+
+	case control.ActionStop:
+		if p.dead {
+			s.unschedule(p)
+			break
+		}
+		if !p.killable {
+			return control.Response{Error: fmt.Sprintf("pane '%s' is not killable", p.key)}
+		}
+		p.kill()
+
+	case control.ActionRestart:
+		p.restarts = 0
+		if p.dead {
+			s.startPane(p)
+			break
+		}
+		if !p.killable {
+			return control.Response{Error: fmt.Sprintf("pane '%s' is not killable", p.key)}
+		}
+		// Started again once the process exited
+		p.kill()
+		p.relaunch = true
+
+	case control.ActionSend:
+		if p.dead {
+			return control.Response{Error: fmt.Sprintf("pane '%s' is not running", p.key)}
+		}
+		if _, err := p.vt.Write([]byte(req.Input)); err != nil {
+			return control.Response{Error: fmt.Sprintf("failed to send input to pane '%s': %v", p.key, err)}
+		}
+
+	case control.ActionDump:
+		lines := strings.Split(p.vt.String(), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+		output := strings.Join(lines, "\n")
+		if req.Scrollback {
+			if scrollback := p.vt.Scrollback(); scrollback != "" {
+				output = scrollback + "\n" + output
+			}
+		}
+		return control.Response{Output: strings.TrimRight(output, "\n")}
+	}
+
+	s.ui.sort()
+	s.ui.draw()
+
+	return control.Response{}
+}
+
+// Describes the pane state for control clients
+func (p *pane) info() control.PaneInfo {
+	info := control.PaneInfo{
+		Name:  p.key,
+		Title: p.title,
+	}
+
+	switch {
+	case p.waiting:
+		info.State = "waiting"
+	case p.pendingRestart != nil:
+		info.State = "restarting"
+	case !p.dead:
+		info.State = "running"
+		if p.cmd != nil && p.cmd.Process != nil {
+			info.PID = p.cmd.Process.Pid
+		}
+	case p.exit != nil:
+		info.State = "exited"
+	default:
+		info.State = "stopped"
+	}
+
+	if !p.dead {
+		switch p.readiness {
+		case readinessStarting:
+			info.Ready = "starting"
+		case readinessReady:
+			info.Ready = "ready"
+		case readinessFailed:
+			info.Ready = "failed"
+		}
+	}
+
+	if p.exit != nil {
+		if p.exit.signaled {
+			info.Signal = p.exit.signalName()
+		} else {
+			code := p.exit.code
+			info.ExitCode = &code
+		}
+		info.Exit = p.exit.String()
+	}
+
+	return info
+}
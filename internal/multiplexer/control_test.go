@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package multiplexer
+
+import (
+	"testing"
+
+	"github.com/nodge/multiplexer/internal/control"
+)
+
+func TestHandleControl_Errors(t *testing.T) {
+	eh := newTestMultiplexer(t)
+	addTestPane(eh, "api", "sleep 60")
+
+	tests := []struct {
+		name string
+		req  control.Request
+		want string
+	}{
+		{name: "unknown action", req: control.Request{Action: "reboot"}, want: "unknown action 'reboot'"},
+		{name: "unknown action of a pane", req: control.Request{Action: "reboot", Pane: "api"}, want: "unknown action 'reboot'"},
+		{name: "unknown pane", req: control.Request{Action: control.ActionStop, Pane: "web"}, want: "pane 'web' not found"},
+		{name: "running pane", req: control.Request{Action: control.ActionStart, Pane: "api"}, want: "pane 'api' is already running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eh.multiplexer.handleControl(tt.req); got.Error != tt.want {
+				t.Errorf("handleControl() error = %q, want %q", got.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package multiplexer
+
+import (
+	"github.com/gdamore/tcell/v2"
+	tcellterm "github.com/nodge/multiplexer/internal/tcell-term"
+)
+
+// Pseudo actions shown in the hotkeys in copy mode
+const (
+	actionCopyMove   = "copy-move"
+	actionCopyWord   = "copy-word"
+	actionCopyVisual = "copy-visual"
+	actionCopyLine   = "copy-line"
+	actionCopyBlock  = "copy-block"
+	actionCopyYank   = "copy-yank"
+	actionCopyExit   = "copy-exit"
+)
+
+// Keys of the copy mode shown in the hotkeys
+var copyModeHotkeys = map[string]string{
+	"h":      actionCopyMove,
+	"j":      actionCopyMove,
+	"k":      actionCopyMove,
+	"l":      actionCopyMove,
+	"w":      actionCopyWord,
+	"b":      actionCopyWord,
+	"v":      actionCopyVisual,
+	"V":      actionCopyLine,
+	"ctrl-v": actionCopyBlock,
+	"y":      actionCopyYank,
+	"q":      actionCopyExit,
+}
+
+// Motions of the keys in copy mode
+var copyMotions = map[string]tcellterm.CopyMotion{
+	"h":      tcellterm.CopyLeft,
+	"left":   tcellterm.CopyLeft,
+	"l":      tcellterm.CopyRight,
+	"right":  tcellterm.CopyRight,
+	"k":      tcellterm.CopyUp,
+	"up":     tcellterm.CopyUp,
+	"j":      tcellterm.CopyDown,
+	"down":   tcellterm.CopyDown,
+	"w":      tcellterm.CopyWordForward,
+	"b":      tcellterm.CopyWordBackward,
+	"0":      tcellterm.CopyLineStart,
+	"home":   tcellterm.CopyLineStart,
+	"$":      tcellterm.CopyLineEnd,
+	"end":    tcellterm.CopyLineEnd,
+	"G":      tcellterm.CopyBottom,
+	"ctrl-u": tcellterm.CopyHalfPageUp,
+	"ctrl-d": tcellterm.CopyHalfPageDown,
+}
+
+// Keys typed in the copy mode of a pane
+type copyPrompt struct {
+	pane    *pane
+	count   int  // repeat count typed before a motion, zero when none
+	pending bool // true after the first g of gg
+}
+
+// Enters copy mode in the selected pane
+func (ui *UI) startCopyMode() bool {
+	selected := ui.selectedPane()
+	if selected == nil {
+		return false
+	}
+
+	selected.vt.EnterCopyMode()
+	ui.copying = &copyPrompt{pane: selected}
+	ui.draw()
+	return true
+}
+
+// Leaves copy mode, removing the selection
+func (ui *UI) stopCopyMode() {
+	ui.copying.pane.vt.ExitCopyMode()
+	ui.copying = nil
+	ui.draw()
+	ui.screen.Sync()
+}
+
+// Handles keyboard input in copy mode, vi motions move the cursor and the
+// selection is yanked to the clipboard
+func (eh *EventLoop) handleCopyKey(evt *tcell.EventKey) {
+	prompt := eh.ui.copying
+	vt := prompt.pane.vt
+	chord := keyChord(evt)
+
+	// Digits repeat the following motion, 0 alone moves to the line start
+	if len(chord) == 1 && chord[0] >= '0' && chord[0] <= '9' && (chord != "0" || prompt.count > 0) {
+		prompt.count = prompt.count*10 + int(chord[0]-'0')
+		return
+	}
+	count := prompt.count
+	pending := prompt.pending
+	prompt.count = 0
+	prompt.pending = false
+
+	motion, isMotion := copyMotions[chord]
+	switch {
+	case chord == "g" && pending:
+		vt.CopyMove(tcellterm.CopyTop, 1)
+
+	case chord == "g":
+		prompt.pending = true
+
+	case isMotion:
+		vt.CopyMove(motion, count)
+
+	case chord == "v":
+		vt.SetCopyVisual(tcellterm.VisualChar)
+
+	case chord == "V":
+		vt.SetCopyVisual(tcellterm.VisualLine)
+
+	case chord == "ctrl-v":
+		vt.SetCopyVisual(tcellterm.VisualBlock)
+
+	case chord == "y" || chord == "enter":
+		if !vt.HasSelection() {
+			return
+		}
+		eh.multiplexer.copy()
+		eh.ui.stopCopyMode()
+		return
+
+	case chord == "esc" && vt.CopyVisual() != tcellterm.VisualNone:
+		vt.SetCopyVisual(vt.CopyVisual())
+
+	case chord == "q" || chord == "esc" || chord == "ctrl-c":
+		eh.ui.stopCopyMode()
+		return
+	}
+
+	eh.ui.draw()
+}
+
+// Draws the copy mode cursor and the mode in the top right corner of the pane
+func (ui *UI) drawCopyMode() {
+	prompt := ui.copying
+	if prompt == nil || !ui.isVisible(prompt.pane) {
+		return
+	}
+
+	area := prompt.pane.content
+	text := "[copy]"
+	switch prompt.pane.vt.CopyVisual() {
+	case tcellterm.VisualChar:
+		text = "[visual]"
+	case tcellterm.VisualLine:
+		text = "[visual line]"
+	case tcellterm.VisualBlock:
+		text = "[visual block]"
+	}
+	style := tcell.StyleDefault.Background(tcell.ColorOrange).Foreground(tcell.ColorBlack)
+	for i, r := range []rune(text) {
+		if x := area.width - len(text) + i; x >= 0 {
+			ui.screen.SetContent(area.x+x, area.y, r, nil, style)
+		}
+	}
+
+	row, col, ok := prompt.pane.vt.CopyCursor()
+	if !ok {
+		ui.screen.HideCursor()
+		return
+	}
+	ui.screen.ShowCursor(area.x+col, area.y+row)
+}
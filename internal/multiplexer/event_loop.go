@@ -6,8 +6,10 @@ import (
 	"os"
 	"runtime/debug"
 	"syscall"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/logfile"
 	"github.com/nodge/multiplexer/internal/process"
 	tcellterm "github.com/nodge/multiplexer/internal/tcell-term"
 )
@@ -15,13 +17,21 @@ import (
 // Represents a request to create or manage a terminal process
 type EventProcess struct {
 	tcell.EventTime
-	Key       string
-	Cmd       []string
-	Env       map[string]string
-	Title     string
-	Cwd       string
-	Killable  bool
-	Autostart bool
+	Key        string
+	Cmd        []string
+	Env        map[string]string
+	Title      string
+	Cwd        string
+	Killable   bool
+	Autostart  bool
+	DependsOn  []string
+	Ready      *ReadyProbe
+	Restart    *RestartPolicy
+	Log        *logfile.Options
+	Scrollback *Scrollback
+	Stop       *process.StopOptions // how the process is stopped, nil for the defaults
+	Select     bool                 // selects the new pane
+	AdHoc      bool                 // started from the new pane prompt, not part of the configuration
 }
 
 // EventExit is a custom event used to signal the multiplexer to shut down gracefully
@@ -29,6 +39,18 @@ type EventExit struct {
 	tcell.EventTime
 }
 
+// EventKeybindings is a custom event used to replace the key bindings
+type EventKeybindings struct {
+	tcell.EventTime
+	Keybindings Keybindings
+}
+
+// EventReengage is a custom event used to switch the client of a session tty
+type EventReengage struct {
+	tcell.EventTime
+	fn func()
+}
+
 // Manages the main event loop and event processing for the multiplexer
 type EventLoop struct {
 	multiplexer *Multiplexer
@@ -95,9 +117,38 @@ func (eh *EventLoop) handleEvent(evt tcell.Event) bool {
 	case *EventProcess:
 		eh.handleProcessEvent(e)
 
+	case *EventReadiness:
+		eh.handleReadinessEvent(e)
+
+	case *EventRestart:
+		eh.handleRestartEvent(e)
+
+	case *EventControl:
+		e.reply <- eh.multiplexer.handleControl(e.Request)
+
+	case *EventReengage:
+		eh.multiplexer.reengage(e.fn)
+
+	case *EventReload:
+		eh.handleReloadEvent(e)
+
+	case *EventKeybindings:
+		eh.ui.keybindings = DefaultKeybindings().merge(e.Keybindings)
+		eh.ui.prefixed = false
+		eh.ui.draw()
+
+	case *EventClipboard:
+		eh.multiplexer.setClipboard(e.Options)
+
 	case *tcell.EventMouse:
 		eh.handleMouseEvent(e)
 
+	case *EventAutoScroll:
+		eh.ui.autoScroll(e)
+
+	case *tcellterm.EventMouseMode:
+		eh.ui.updateMouse()
+
 	case *tcell.EventResize:
 		eh.handleResizeEvent(e)
 
@@ -107,6 +158,9 @@ func (eh *EventLoop) handleEvent(evt tcell.Event) bool {
 	case *tcellterm.EventClosed:
 		eh.handleClosedEvent(e)
 
+	case *tcell.EventPaste:
+		eh.handlePasteEvent(e)
+
 	case *tcell.EventKey:
 		eh.handleKeyEvent(e)
 	}
@@ -124,35 +178,87 @@ func (eh *EventLoop) handleProcessEvent(evt *EventProcess) {
 	for _, p := range eh.multiplexer.panes {
 		if p.key == evt.Key {
 			if p.dead && evt.Autostart {
-				p.start()
+				eh.multiplexer.schedule(p)
 			}
 			return
 		}
 	}
 
 	p := eh.multiplexer.addPane(&pane{
-		key:      evt.Key,
-		args:     evt.Cmd,
-		env:      evt.Env,
-		dir:      evt.Cwd,
-		title:    evt.Title,
-		killable: evt.Killable,
+		key:        evt.Key,
+		args:       evt.Cmd,
+		env:        evt.Env,
+		dir:        evt.Cwd,
+		title:      evt.Title,
+		killable:   evt.Killable,
+		dependsOn:  evt.DependsOn,
+		ready:      evt.Ready,
+		restart:    evt.Restart,
+		logOptions: evt.Log,
+		stop:       evt.Stop,
+		adHoc:      evt.AdHoc,
 	})
+	if evt.Scrollback != nil {
+		p.vt.SetScrollback(evt.Scrollback.Lines, evt.Scrollback.Spill)
+	}
+	if evt.Select {
+		eh.ui.selected = p.key
+		eh.ui.checkFocus()
+	}
 
 	if evt.Autostart {
-		p.start()
+		eh.multiplexer.schedule(p)
 	}
 
 	if !evt.Autostart {
-		p.vt.Start(process.Command("echo", p.key+" has auto-start disabled, press enter to start."))
+		p.showMessage(p.key + " has auto-start disabled, press enter to start.")
 		p.dead = true
 	}
 }
 
-// Handles mouse events for scrolling, selection, and process switching
+// Handles readiness probe results and starts the panes waiting for them
+func (eh *EventLoop) handleReadinessEvent(evt *EventReadiness) {
+	p := eh.multiplexer.findPane(evt.Key)
+	if p == nil || p.probe != evt.probe {
+		return // result of a probe from a previous start
+	}
+
+	p.probe = nil
+	p.readiness = readinessFailed
+	if evt.Ready {
+		p.readiness = readinessReady
+	}
+
+	eh.multiplexer.startWaiting()
+	eh.ui.sort()
+	eh.ui.draw()
+}
+
+// Handles pending restarts, redraws the countdown and restarts the pane when it is due
+func (eh *EventLoop) handleRestartEvent(evt *EventRestart) {
+	p := eh.multiplexer.findPane(evt.Key)
+	if p == nil || p.pendingRestart != evt.restart {
+		return // restart was cancelled or replaced
+	}
+
+	if evt.Due {
+		p.pendingRestart = nil
+		eh.multiplexer.startPane(p)
+		eh.ui.sort()
+	}
+
+	eh.ui.draw()
+}
+
+// Handles mouse events for scrolling, selection, process switching, and forwarding to processes
 func (eh *EventLoop) handleMouseEvent(evt *tcell.EventMouse) {
 	const MOUSE_SCROLL_SPEED = 3
 
+	// Processes tracking the mouse get the events in their pane
+	if eh.ui.forwardMouse(evt) {
+		return
+	}
+
 	if evt.Buttons()&tcell.WheelUp != 0 {
 		eh.multiplexer.scrollUp(MOUSE_SCROLL_SPEED)
 		return
@@ -176,15 +282,31 @@ func (eh *EventLoop) handleMouseEvent(evt *tcell.EventMouse) {
 	if evt.Buttons()&tcell.ButtonPrimary != 0 {
 		x, y := evt.Position()
 
+		// Dragging extends the selection, also past the edges of the pane
+		if eh.ui.dragging {
+			eh.ui.dragSelection(x, y)
+			return
+		}
+
+		// The search prompt and the copy mode keep their pane until they are closed
+		if eh.ui.search != nil || eh.ui.copying != nil {
+			return
+		}
+
 		// Click in sidebar - switch to selected process
 		if eh.ui.isSidebarClick(x) {
 			eh.ui.selectPaneByCoordinates(x, y)
 			return
 		}
 
+		// Click in another split - move the focus to it
+		if eh.ui.isTerminalClick(x) && eh.ui.selectPaneAt(x, y) {
+			return
+		}
+
 		// Click in main terminal area - handle text selection
 		if eh.ui.isTerminalClick(x) {
-			eh.ui.handleSelection(x, y)
+			eh.ui.handleSelection(evt)
 			return
 		}
 	}
@@ -199,21 +321,43 @@ func (eh *EventLoop) handleResizeEvent(evt *tcell.EventResize) {
 
 // Handles terminal redraw requests from the virtual terminal
 func (eh *EventLoop) handleRedrawEvent(evt *tcellterm.EventRedraw) {
-	selected := eh.ui.selectedPane()
-	if selected != nil && selected.vt == evt.VT() {
-		selected.vt.Draw()
-		eh.ui.screen.Show()
+	for _, p := range eh.ui.visiblePanes() {
+		if p.vt == evt.VT() {
+			p.vt.Draw()
+			eh.ui.drawSearchPrompt()
+			eh.ui.drawCopyMode()
+			eh.ui.drawNewPanePrompt()
+			eh.ui.screen.Show()
+		}
 	}
 }
 
 // Handles process termination events
 func (eh *EventLoop) handleClosedEvent(evt *tcellterm.EventClosed) {
 	for _, proc := range eh.multiplexer.panes {
-		if proc.vt == evt.VT() {
+		// Ignore messages shown in the terminal and processes replaced by a restart
+		if proc.vt == evt.VT() && proc.cmd == evt.Cmd() {
 			if !proc.dead {
-				// Show exit message and mark process as dead
-				proc.vt.Start(process.Command("echo", "\n[process exited]"))
+				// Mark process as dead
+				proc.exit = newExitStatus(evt.Cmd().ProcessState, time.Since(proc.startedAt))
 				proc.dead = true
+				proc.stopProbe()
+				proc.readiness = readinessNone
+
+				if proc.relaunch {
+					// Killed to be started again, e.g. after its command changed
+					proc.relaunch = false
+					eh.multiplexer.schedule(proc)
+					eh.ui.sort()
+					continue
+				}
+
+				// Show exit message
+				proc.showMessage("\n[process " + proc.exit.String() + "]")
+
+				if proc.shouldRestart(!proc.exit.success()) {
+					proc.scheduleRestart(eh.multiplexer.ctx, eh.multiplexer.postEvent)
+				}
 
 				// Exit focus mode if the closed process was selected
 				if proc.key == eh.ui.selected {
@@ -228,63 +372,96 @@ func (eh *EventLoop) handleClosedEvent(evt *tcellterm.EventClosed) {
 	eh.ui.draw()
 }
 
-// Handles keyboard events for navigation and terminal interaction
+// Handles keyboard events, runs the bound actions and forwards other keys to the focused terminal
 func (eh *EventLoop) handleKeyEvent(evt *tcell.EventKey) {
 	selected := eh.ui.selectedPane()
-	PAGE_MOVE_SPEED := eh.ui.screenHeight/2 + 1
+	keys := eh.ui.keybindings
+	chord := keyChord(evt)
 
-	switch evt.Key() {
-	case 256: // Regular character keys
-		switch evt.Rune() {
-		case 'j': // Vi-style down navigation
-			if !eh.ui.focused {
-				eh.ui.move(1)
-				return
-			}
+	// Pasted keys are collected and never run key bindings
+	if eh.ui.pasting != nil {
+		eh.ui.pasting.WriteString(pasteKey(evt))
+		return
+	}
 
-		case 'k': // Vi-style up navigation
-			if !eh.ui.focused {
-				eh.ui.move(-1)
-				return
-			}
+	// The new pane prompt takes all keys
+	if eh.ui.creating != nil {
+		eh.handleNewPaneKey(evt)
+		return
+	}
 
-		case 'x': // Kill selected process
-			if selected != nil && selected.killable && !selected.dead && !eh.ui.focused {
-				selected.kill()
-			}
-		}
+	// So does the open search prompt
+	if eh.ui.search != nil {
+		eh.handleSearchKey(evt)
+		return
+	}
 
-	case tcell.KeyUp:
-		if !eh.ui.focused {
-			eh.ui.move(-1)
-			return
-		}
+	// So does the copy mode, unless it ended in the meantime
+	if eh.ui.copying != nil && (eh.ui.copying.pane != selected || !selected.vt.IsCopying()) {
+		eh.ui.copying = nil
+	}
+	if eh.ui.copying != nil {
+		eh.handleCopyKey(evt)
+		return
+	}
 
-	case tcell.KeyDown:
-		if !eh.ui.focused {
-			eh.ui.move(1)
+	// The key after the prefix runs a prefixed action, pressing the prefix
+	// twice sends it to the focused terminal
+	if eh.ui.prefixed {
+		eh.ui.prefixed = false
+		if chord == keys.Prefix && eh.ui.focused {
+			eh.forwardKey(evt, selected)
 			return
 		}
+		eh.runAction(keys.Prefixed[chord], selected)
+		eh.ui.draw()
+		return
+	}
 
-	case tcell.KeyCtrlU:
-		if selected != nil {
-			eh.multiplexer.scrollUp(PAGE_MOVE_SPEED)
-			return
-		}
+	if keys.Prefix != "" && chord == keys.Prefix {
+		eh.ui.prefixed = true
+		eh.ui.draw()
+		return
+	}
 
-	case tcell.KeyCtrlD:
-		if selected != nil {
-			eh.multiplexer.scrollDown(PAGE_MOVE_SPEED)
-			return
-		}
+	if !eh.ui.focused {
+		eh.runAction(keys.Sidebar[chord], selected)
+		return
+	}
 
-	case tcell.KeyEnter:
+	if !eh.runAction(keys.Focused[chord], selected) {
+		eh.forwardKey(evt, selected)
+	}
+}
+
+// Forwards a keyboard event to the focused terminal
+func (eh *EventLoop) forwardKey(evt *tcell.EventKey, selected *pane) {
+	if selected != nil && eh.ui.focused && !selected.isScrolling() {
+		selected.vt.HandleEvent(evt)
+		eh.ui.draw()
+	}
+}
+
+// Runs a key binding action, returns false if the action does not apply
+func (eh *EventLoop) runAction(action string, selected *pane) bool {
+	PAGE_MOVE_SPEED := eh.ui.screenHeight/2 + 1
+
+	switch action {
+	case ActionUp:
+		eh.ui.move(-1)
+		eh.ui.checkFocus()
+
+	case ActionDown:
+		eh.ui.move(1)
+		eh.ui.checkFocus()
+
+	case ActionSelect:
 		// Copy selected text if there's an active selection
 		if selected != nil && selected.vt.HasSelection() {
 			eh.multiplexer.copy()
 			selected.vt.ClearSelection()
 			eh.ui.draw()
-			return
+			return true
 		}
 
 		// Reset scroll position when scrolled up
@@ -292,43 +469,111 @@ func (eh *EventLoop) handleKeyEvent(evt *tcell.EventKey) {
 			selected.scrollReset()
 			eh.ui.draw()
 			eh.ui.screen.Sync()
-			return
+			return true
+		}
+
+		// Focused terminals receive the key
+		if eh.ui.focused {
+			return false
 		}
 
 		// Enter focus mode or restart dead process
-		if !eh.ui.focused {
-			if selected != nil && selected.killable {
-				if selected.dead {
-					selected.start()
-					eh.ui.sort()
-					eh.ui.draw()
-					return
-				}
-				eh.ui.focus()
+		if selected != nil && selected.killable {
+			if selected.dead {
+				selected.restarts = 0
+				eh.multiplexer.startPane(selected)
+				eh.ui.sort()
+				eh.ui.draw()
+				return true
 			}
-			return
+			eh.ui.focus()
 		}
 
-	case tcell.KeyCtrlC:
-		// Exit multiplexer when not focused on a terminal
+	case ActionSidebar: // Exit focus mode
 		if !eh.ui.focused {
-			eh.ui.move(-99999) // Move to top
-			pid := os.Getpid()
-			process, _ := os.FindProcess(pid)
-			process.Signal(syscall.SIGINT)
-			return
+			return false
 		}
+		eh.ui.blur()
 
-	case tcell.KeyCtrlZ: // Exit focus mode
-		if eh.ui.focused {
-			eh.ui.blur()
-			return
+	case ActionKill: // Kill selected process, cancel waiting for its dependencies or its pending restart
+		if selected != nil && (selected.waiting || selected.pendingRestart != nil) {
+			eh.multiplexer.unschedule(selected)
+			eh.ui.draw()
+			return true
+		}
+		if selected != nil && selected.killable && !selected.dead {
+			selected.kill()
 		}
-	}
 
-	// Forward keyboard events to the focused terminal
-	if selected != nil && eh.ui.focused && !selected.isScrolling() {
-		selected.vt.HandleEvent(evt)
+	case ActionScrollUp:
+		if selected == nil {
+			return false
+		}
+		eh.multiplexer.scrollUp(PAGE_MOVE_SPEED)
+
+	case ActionScrollDown:
+		if selected == nil {
+			return false
+		}
+		eh.multiplexer.scrollDown(PAGE_MOVE_SPEED)
+
+	case ActionSplit: // Pin the selected pane to the splits or unpin it
+		eh.ui.toggleSplit()
+
+	case ActionLayout: // Switch to the next layout
+		eh.ui.cycleLayout()
+
+	case ActionNextSplit: // Move to the next split
+		eh.ui.cycleSplit()
+
+	case ActionSearch, ActionSearchBackward: // Open the search prompt
+		return eh.ui.startSearch(action == ActionSearchBackward)
+
+	case ActionSearchNext, ActionSearchPrev: // Jump between search matches
+		if selected == nil || !selected.vt.IsSearching() {
+			return false
+		}
+		selected.vt.SearchNext(action == ActionSearchPrev)
+		eh.ui.draw()
+		eh.ui.screen.Sync()
+
+	case ActionCopyMode: // Move a cursor over the output to select text
+		return eh.ui.startCopyMode()
+
+	case ActionNewPane: // Ask for the command of a new pane
+		return eh.ui.startNewPane()
+
+	case ActionRemove: // Remove the selected pane once its process has ended
+		if selected == nil || !selected.dead {
+			return false
+		}
+		eh.multiplexer.removePane(selected)
+		eh.ui.sort()
 		eh.ui.draw()
+
+	case ActionSearchClear: // Remove the search highlighting
+		if selected == nil || !selected.vt.IsSearching() {
+			return false
+		}
+		selected.vt.ClearSearch()
+		eh.ui.draw()
+
+	case ActionDetach: // Detach from the session
+		if eh.multiplexer.detach == nil {
+			return false
+		}
+		eh.multiplexer.detach()
+		eh.multiplexer.reengage(func() {})
+
+	case ActionQuit: // Exit the multiplexer
+		eh.ui.move(-99999) // Move to top
+		pid := os.Getpid()
+		process, _ := os.FindProcess(pid)
+		process.Signal(syscall.SIGINT)
+
+	default:
+		return false
 	}
+
+	return true
 }
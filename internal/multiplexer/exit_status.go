@@ -0,0 +1,86 @@
+package multiplexer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Short names of common terminating signals
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "HUP",
+	syscall.SIGINT:  "INT",
+	syscall.SIGQUIT: "QUIT",
+	syscall.SIGILL:  "ILL",
+	syscall.SIGABRT: "ABRT",
+	syscall.SIGBUS:  "BUS",
+	syscall.SIGFPE:  "FPE",
+	syscall.SIGKILL: "KILL",
+	syscall.SIGSEGV: "SEGV",
+	syscall.SIGPIPE: "PIPE",
+	syscall.SIGALRM: "ALRM",
+	syscall.SIGTERM: "TERM",
+}
+
+// Exit status of a finished pane process
+type exitStatus struct {
+	code     int            // exit code, -1 when terminated by a signal
+	signal   syscall.Signal // terminating signal, valid when signaled is true
+	signaled bool
+	runtime  time.Duration
+}
+
+// Creates the exit status from the state of a waited process
+func newExitStatus(state *os.ProcessState, runtime time.Duration) *exitStatus {
+	status := &exitStatus{code: -1, runtime: runtime}
+	if state == nil {
+		return status
+	}
+
+	status.code = state.ExitCode()
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.signal = ws.Signal()
+		status.signaled = true
+	}
+
+	return status
+}
+
+// Returns true if the process exited with code 0
+func (e *exitStatus) success() bool {
+	return !e.signaled && e.code == 0
+}
+
+// Returns the signal name, e.g. SIGTERM
+func (e *exitStatus) signalName() string {
+	if name, ok := signalNames[e.signal]; ok {
+		return "SIG" + name
+	}
+	return "signal " + strconv.Itoa(int(e.signal))
+}
+
+// Returns the exit code or the signal name for the sidebar
+func (e *exitStatus) short() string {
+	if e.signaled {
+		if name, ok := signalNames[e.signal]; ok {
+			return name
+		}
+		return "sig" + strconv.Itoa(int(e.signal))
+	}
+	return strconv.Itoa(e.code)
+}
+
+// Describes how the process exited and how long it ran
+func (e *exitStatus) String() string {
+	runtime := e.runtime.Round(time.Millisecond)
+	if e.runtime >= time.Second {
+		runtime = e.runtime.Round(time.Second)
+	}
+
+	if e.signaled {
+		return fmt.Sprintf("killed by %s after %s", e.signalName(), runtime)
+	}
+	return fmt.Sprintf("exited with code %d after %s", e.code, runtime)
+}
@@ -9,6 +9,27 @@ import (
 	"github.com/gdamore/tcell/v2/views"
 )
 
+// Actions shown as a single hotkey when both apply, with the shared label or
+// the label of the first action
+var hotkeyPairs = []struct {
+	first  string
+	second string
+	label  string
+}{
+	{ActionDown, ActionUp, "up/down"},
+	{ActionScrollUp, ActionScrollDown, "scroll"},
+	{ActionSearch, ActionSearchBackward, ""},
+	{ActionSearchNext, ActionSearchPrev, ""},
+}
+
+// Shorter symbols for named keys
+var hotkeySymbols = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+}
+
 // Widget for displaying available keyboard shortcuts
 type HotkeysWidget struct {
 	menu *views.BoxLayout
@@ -21,52 +42,54 @@ func NewHotkeysWidget(menu *views.BoxLayout) *HotkeysWidget {
 	}
 }
 
-// Generates and displays the hotkeys based on current state
-func (h *HotkeysWidget) render(selected *pane, focused bool) {
-	hotkeys := map[string]string{}
-
-	if selected != nil && selected.killable && !focused {
-		if !selected.dead {
-			hotkeys["x"] = "kill"
-			hotkeys["enter"] = "focus"
-		}
-
-		if selected.dead {
-			hotkeys["enter"] = "start"
+// Generates and displays the hotkeys from the bindings, skipping the actions
+// without a label in the current state
+func (h *HotkeysWidget) render(bindings map[string]string, label func(action string) string) {
+	// Group the chords by action, single characters first
+	chords := map[string][]string{}
+	for chord, action := range bindings {
+		if label(action) != "" {
+			chords[action] = append(chords[action], chord)
 		}
 	}
-
-	if !focused {
-		hotkeys["j/k/↓/↑"] = "up/down"
+	for _, list := range chords {
+		slices.SortFunc(list, compareHotkeys)
 	}
 
-	if focused {
-		hotkeys["ctrl-z"] = "sidebar"
-	}
+	hotkeys := map[string]string{}
+	for _, pair := range hotkeyPairs {
+		first, second := chords[pair.first], chords[pair.second]
+		if len(first) == 0 || len(second) == 0 {
+			continue
+		}
 
-	if selected != nil && selected.isScrolling() && (focused || !selected.killable) {
-		hotkeys["enter"] = "reset"
+		// Interleave the chords, e.g. j/k/↓/↑
+		var keys []string
+		for i := range max(len(first), len(second)) {
+			if i < len(first) {
+				keys = append(keys, first[i])
+			}
+			if i < len(second) {
+				keys = append(keys, second[i])
+			}
+		}
+		hotkeys[hotkeyText(keys)] = pair.label
+		if pair.label == "" {
+			hotkeys[hotkeyText(keys)] = label(pair.first)
+		}
+		delete(chords, pair.first)
+		delete(chords, pair.second)
 	}
-
-	if selected != nil && selected.vt.HasSelection() {
-		hotkeys["enter"] = "copy"
+	for action, keys := range chords {
+		hotkeys[hotkeyText(keys)] = label(action)
 	}
 
-	hotkeys["ctrl-u/d"] = "scroll"
-
 	// Sort hotkeys by length first, then alphabetically
 	keys := make([]string, 0, len(hotkeys))
 	for key := range hotkeys {
 		keys = append(keys, key)
 	}
-	slices.SortFunc(keys, func(i, j string) int {
-		ilength := utf8.RuneCountInString(i)
-		jlength := utf8.RuneCountInString(j)
-		if ilength != jlength {
-			return ilength - jlength
-		}
-		return strings.Compare(i, j)
-	})
+	slices.SortFunc(keys, compareHotkeys)
 
 	for _, key := range keys {
 		label := hotkeys[key]
@@ -77,3 +100,46 @@ func (h *HotkeysWidget) render(selected *pane, focused bool) {
 		h.menu.AddWidget(title, 0)
 	}
 }
+
+// Orders hotkeys by length first, then alphabetically
+func compareHotkeys(i, j string) int {
+	ilength := utf8.RuneCountInString(i)
+	jlength := utf8.RuneCountInString(j)
+	if ilength != jlength {
+		return ilength - jlength
+	}
+	return strings.Compare(i, j)
+}
+
+// Joins chords for display, omitting modifiers repeated from the previous chord,
+// e.g. ctrl-u/d. Chords are separated by spaces when one of them is a slash.
+func hotkeyText(chords []string) string {
+	parts := make([]string, len(chords))
+	previous := ""
+	for i, chord := range chords {
+		modifiers, key := splitChord(chord)
+		if symbol, ok := hotkeySymbols[key]; ok {
+			key = symbol
+		}
+		parts[i] = modifiers + key
+		if i > 0 && modifiers != "" && modifiers == previous {
+			parts[i] = key
+		}
+		previous = modifiers
+	}
+	if slices.Contains(chords, "/") {
+		return strings.Join(parts, " ")
+	}
+	return strings.Join(parts, "/")
+}
+
+// Splits a canonical chord into its modifiers, e.g. `ctrl-`, and its key
+func splitChord(chord string) (string, string) {
+	index := 0
+	for _, modifier := range []string{"ctrl-", "alt-", "shift-"} {
+		if strings.HasPrefix(chord[index:], modifier) && len(chord) > index+len(modifier) {
+			index += len(modifier)
+		}
+	}
+	return chord[:index], chord[index:]
+}
@@ -0,0 +1,369 @@
+package multiplexer
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Key binding actions
+const (
+	ActionNone       = "none"
+	ActionUp         = "up"
+	ActionDown       = "down"
+	ActionSelect     = "select"
+	ActionSidebar    = "sidebar"
+	ActionKill       = "kill"
+	ActionScrollUp   = "scroll-up"
+	ActionScrollDown = "scroll-down"
+	ActionSplit      = "split"
+	ActionLayout     = "layout"
+	ActionNextSplit  = "next-split"
+	ActionDetach     = "detach"
+	ActionQuit       = "quit"
+
+	ActionSearch         = "search"
+	ActionSearchBackward = "search-backward"
+	ActionSearchNext     = "search-next"
+	ActionSearchPrev     = "search-prev"
+	ActionSearchClear    = "search-clear"
+
+	ActionCopyMode = "copy-mode"
+
+	ActionNewPane = "new-pane"
+	ActionRemove  = "remove"
+)
+
+// Pseudo actions shown in the hotkeys for the prefix key itself
+const (
+	actionPrefix     = "prefix"
+	actionSendPrefix = "send-prefix"
+)
+
+// Keybindings maps key chords in their canonical form, e.g. `j`, `J`,
+// `ctrl-a`, `alt-x` or `shift-tab`, to actions for every input mode
+type Keybindings struct {
+	Prefix   string            // chord that starts a prefix sequence, empty disables the prefix mode
+	Sidebar  map[string]string // bindings active in the sidebar
+	Focused  map[string]string // bindings active in a focused pane, other keys are sent to the pane
+	Prefixed map[string]string // bindings active for the key pressed after the prefix
+}
+
+// DefaultKeybindings returns the built-in key bindings
+func DefaultKeybindings() Keybindings {
+	return Keybindings{
+		Sidebar: map[string]string{
+			"j":      ActionDown,
+			"down":   ActionDown,
+			"k":      ActionUp,
+			"up":     ActionUp,
+			"enter":  ActionSelect,
+			"x":      ActionKill,
+			"d":      ActionDetach,
+			"s":      ActionSplit,
+			"l":      ActionLayout,
+			"tab":    ActionNextSplit,
+			"ctrl-u": ActionScrollUp,
+			"ctrl-d": ActionScrollDown,
+			"ctrl-c": ActionQuit,
+			"/":      ActionSearch,
+			"?":      ActionSearchBackward,
+			"n":      ActionSearchNext,
+			"N":      ActionSearchPrev,
+			"esc":    ActionSearchClear,
+			"[":      ActionCopyMode,
+			"c":      ActionNewPane,
+			"X":      ActionRemove,
+		},
+		Focused: map[string]string{
+			"enter":  ActionSelect,
+			"ctrl-z": ActionSidebar,
+			"ctrl-u": ActionScrollUp,
+			"ctrl-d": ActionScrollDown,
+		},
+		Prefixed: map[string]string{
+			"j":      ActionDown,
+			"down":   ActionDown,
+			"k":      ActionUp,
+			"up":     ActionUp,
+			"enter":  ActionSelect,
+			"esc":    ActionSidebar,
+			"x":      ActionKill,
+			"d":      ActionDetach,
+			"s":      ActionSplit,
+			"l":      ActionLayout,
+			"tab":    ActionNextSplit,
+			"ctrl-u": ActionScrollUp,
+			"ctrl-d": ActionScrollDown,
+			"/":      ActionSearch,
+			"?":      ActionSearchBackward,
+			"n":      ActionSearchNext,
+			"N":      ActionSearchPrev,
+			"[":      ActionCopyMode,
+			"c":      ActionNewPane,
+			"X":      ActionRemove,
+		},
+	}
+}
+
+// Returns the bindings with the overrides applied, ActionNone removes a binding
+func (k Keybindings) merge(overrides Keybindings) Keybindings {
+	apply := func(bindings map[string]string, overrides map[string]string) map[string]string {
+		result := maps.Clone(bindings)
+		for chord, action := range overrides {
+			result[chord] = action
+			if action == ActionNone {
+				delete(result, chord)
+			}
+		}
+		return result
+	}
+
+	result := Keybindings{
+		Prefix:   k.Prefix,
+		Sidebar:  apply(k.Sidebar, overrides.Sidebar),
+		Focused:  apply(k.Focused, overrides.Focused),
+		Prefixed: apply(k.Prefixed, overrides.Prefixed),
+	}
+	if overrides.Prefix != "" {
+		result.Prefix = overrides.Prefix
+	}
+	return result
+}
+
+// Names of the keys used in chords
+var keyNames = map[tcell.Key]string{
+	tcell.KeyEnter:      "enter",
+	tcell.KeyTab:        "tab",
+	tcell.KeyEscape:     "esc",
+	tcell.KeyBackspace:  "backspace",
+	tcell.KeyBackspace2: "backspace",
+	tcell.KeyDelete:     "delete",
+	tcell.KeyInsert:     "insert",
+	tcell.KeyUp:         "up",
+	tcell.KeyDown:       "down",
+	tcell.KeyLeft:       "left",
+	tcell.KeyRight:      "right",
+	tcell.KeyHome:       "home",
+	tcell.KeyEnd:        "end",
+	tcell.KeyPgUp:       "pgup",
+	tcell.KeyPgDn:       "pgdn",
+}
+
+// Returns the canonical chord of a key event, or an empty string for keys that cannot be bound
+func keyChord(evt *tcell.EventKey) string {
+	mods := evt.Modifiers()
+	key := evt.Key()
+
+	var name string
+	switch {
+	case key == tcell.KeyRune:
+		name = string(evt.Rune())
+		if evt.Rune() == ' ' {
+			name = "space"
+		}
+		// Shifted characters arrive as upper case letters or symbols
+		mods &^= tcell.ModShift
+	case keyNames[key] != "":
+		name = keyNames[key]
+	case key == tcell.KeyBacktab:
+		name = "tab"
+		mods |= tcell.ModShift
+	case key == tcell.KeyCtrlSpace:
+		name = "space"
+		mods |= tcell.ModCtrl
+	case key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ:
+		name = string(rune('a' + key - tcell.KeyCtrlA))
+		mods |= tcell.ModCtrl
+	case key >= tcell.KeyF1 && key <= tcell.KeyF12:
+		name = "f" + strconv.Itoa(int(key-tcell.KeyF1)+1)
+	default:
+		return ""
+	}
+
+	var chord strings.Builder
+	if mods&tcell.ModCtrl != 0 {
+		chord.WriteString("ctrl-")
+	}
+	if mods&tcell.ModAlt != 0 {
+		chord.WriteString("alt-")
+	}
+	if mods&tcell.ModShift != 0 {
+		chord.WriteString("shift-")
+	}
+	chord.WriteString(name)
+	return chord.String()
+}
+
+// Returns the bindings of the current input mode, including the prefix key itself
+func (ui *UI) activeBindings() map[string]string {
+	keys := ui.keybindings
+
+	var result map[string]string
+	switch {
+	case ui.creating != nil:
+		return maps.Clone(newPaneHotkeys)
+	case ui.copying != nil:
+		return maps.Clone(copyModeHotkeys)
+	case ui.prefixed:
+		result = maps.Clone(keys.Prefixed)
+		if ui.focused {
+			result[keys.Prefix] = actionSendPrefix
+		}
+	case ui.focused:
+		result = maps.Clone(keys.Focused)
+	default:
+		result = maps.Clone(keys.Sidebar)
+	}
+
+	if keys.Prefix != "" && !ui.prefixed {
+		result[keys.Prefix] = actionPrefix
+	}
+	return result
+}
+
+// Returns the hotkey label of the action for the current state, or an empty
+// string if the action does not apply
+func (ui *UI) actionLabel(action string) string {
+	selected := ui.selectedPane()
+
+	switch action {
+	case ActionUp, ActionDown, ActionLayout, ActionQuit:
+		return action
+
+	case ActionSelect:
+		switch {
+		case selected == nil:
+			return ""
+		case selected.vt.HasSelection():
+			return "copy"
+		case selected.isScrolling() && (ui.focused || !selected.killable):
+			return "reset"
+		case ui.focused || !selected.killable:
+			return ""
+		case selected.dead:
+			return "start"
+		}
+		return "focus"
+
+	case ActionSidebar:
+		if ui.focused {
+			return "sidebar"
+		}
+
+	case ActionKill:
+		switch {
+		case selected == nil:
+			return ""
+		case selected.waiting || selected.pendingRestart != nil:
+			return "cancel"
+		case selected.killable && !selected.dead:
+			return "kill"
+		}
+
+	case ActionScrollUp:
+		return "scroll up"
+
+	case ActionScrollDown:
+		return "scroll down"
+
+	case ActionSplit:
+		if selected != nil && slices.Contains(ui.splits, selected.key) {
+			return "unsplit"
+		}
+		if selected != nil {
+			return "split"
+		}
+
+	case ActionNextSplit:
+		if ui.layout != layoutSingle {
+			return "next split"
+		}
+
+	case ActionDetach:
+		if ui.detachable {
+			return "detach"
+		}
+
+	case ActionSearch, ActionSearchBackward:
+		if selected != nil {
+			return "search"
+		}
+
+	case ActionSearchNext, ActionSearchPrev:
+		if selected == nil || !selected.vt.IsSearching() {
+			return ""
+		}
+		index, total := selected.vt.SearchResult()
+		if total == 0 {
+			return "no match"
+		}
+		return "match " + strconv.Itoa(index) + "/" + strconv.Itoa(total)
+
+	case ActionSearchClear:
+		if selected != nil && selected.vt.IsSearching() {
+			return "clear"
+		}
+
+	case ActionCopyMode:
+		if selected != nil {
+			return "copy mode"
+		}
+
+	case ActionNewPane:
+		return "new pane"
+
+	case ActionRemove:
+		if selected != nil && selected.dead {
+			return "remove"
+		}
+
+	case actionPromptAccept:
+		if ui.creating != nil && ui.creating.field == fieldCwd {
+			return "create"
+		}
+		return "next"
+
+	case actionPromptCancel:
+		return "cancel"
+
+	case actionPromptHistory:
+		if ui.creating != nil && ui.creating.field == fieldCommand && len(ui.history) > 0 {
+			return "history"
+		}
+
+	case actionCopyMove:
+		return "move"
+
+	case actionCopyWord:
+		return "word"
+
+	case actionCopyVisual:
+		return "visual"
+
+	case actionCopyLine:
+		return "line"
+
+	case actionCopyBlock:
+		return "block"
+
+	case actionCopyYank:
+		if selected != nil && selected.vt.HasSelection() {
+			return "yank"
+		}
+
+	case actionCopyExit:
+		return "exit"
+
+	case actionPrefix:
+		return "prefix"
+
+	case actionSendPrefix:
+		return "send"
+	}
+
+	return ""
+}
@@ -0,0 +1,61 @@
+package multiplexer
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/config"
+)
+
+func TestKeyChord(t *testing.T) {
+	tests := []struct {
+		name string
+		evt  *tcell.EventKey
+		want string
+	}{
+		{name: "letter", evt: tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone), want: "j"},
+		{name: "upper case letter", evt: tcell.NewEventKey(tcell.KeyRune, 'J', tcell.ModShift), want: "J"},
+		{name: "alt letter", evt: tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), want: "alt-x"},
+		{name: "space", evt: tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), want: "space"},
+		{name: "ctrl letter", evt: tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModCtrl), want: "ctrl-a"},
+		{name: "ctrl space", evt: tcell.NewEventKey(tcell.KeyCtrlSpace, 0, tcell.ModCtrl), want: "ctrl-space"},
+		{name: "enter", evt: tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), want: "enter"},
+		{name: "backtab", evt: tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModNone), want: "shift-tab"},
+		{name: "backspace", evt: tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), want: "backspace"},
+		{name: "ctrl backspace", evt: tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModCtrl), want: "ctrl-backspace"},
+		{name: "ctrl arrow", evt: tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModCtrl), want: "ctrl-up"},
+		{name: "function key", evt: tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), want: "f5"},
+		{name: "unbindable key", evt: tcell.NewEventKey(tcell.KeyPause, 0, tcell.ModNone), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyChord(tt.evt); got != tt.want {
+				t.Errorf("keyChord() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The chords of key events match the normalized chords of the configuration
+func TestKeyChord_Normalized(t *testing.T) {
+	tests := []struct {
+		chord string
+		evt   *tcell.EventKey
+	}{
+		{chord: "ctrl-h", evt: tcell.NewEventKey(tcell.KeyBackspace, 0, tcell.ModNone)},
+		{chord: "ctrl-i", evt: tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone)},
+		{chord: "shift-j", evt: tcell.NewEventKey(tcell.KeyRune, 'J', tcell.ModShift)},
+		{chord: "Ctrl-B", evt: tcell.NewEventKey(tcell.KeyCtrlB, 0, tcell.ModCtrl)},
+	}
+
+	for _, tt := range tests {
+		want, err := config.NormalizeChord(tt.chord)
+		if err != nil {
+			t.Fatalf("NormalizeChord(%q) error = %v", tt.chord, err)
+		}
+		if got := keyChord(tt.evt); got != want {
+			t.Errorf("keyChord() of %q = %q, want %q", tt.chord, got, want)
+		}
+	}
+}
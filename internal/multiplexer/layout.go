@@ -0,0 +1,218 @@
+package multiplexer
+
+import (
+	"math"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Layout defines how the visible panes are arranged in the main area
+type layout int
+
+const (
+	layoutSingle     layout = iota // only the selected pane, without borders
+	layoutHorizontal               // panes side by side
+	layoutVertical                 // panes stacked on top of each other
+	layoutGrid                     // panes in rows and columns
+)
+
+// Returns the layout that follows this one when cycling through them
+func (l layout) next() layout {
+	return (l + 1) % (layoutGrid + 1)
+}
+
+// Rectangular area of the screen
+type rect struct {
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+// Returns true if the point lies inside the area
+func (r rect) contains(x int, y int) bool {
+	return x >= r.x && x < r.x+r.width && y >= r.y && y < r.y+r.height
+}
+
+// Returns the area without its one cell wide border
+func (r rect) inner() rect {
+	return rect{r.x + 1, r.y + 1, max(r.width-2, 1), max(r.height-2, 1)}
+}
+
+// Divides the area into n parts according to the layout
+func (l layout) split(area rect, n int) []rect {
+	switch {
+	case n <= 1 || l == layoutSingle:
+		return []rect{area}
+	case l == layoutHorizontal:
+		return splitColumns(area, n)
+	case l == layoutVertical:
+		return splitRows(area, n)
+	}
+
+	// Grid, as square as possible and filled row by row
+	columns := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + columns - 1) / columns
+
+	result := make([]rect, 0, n)
+	for i, row := range splitRows(area, rows) {
+		result = append(result, splitColumns(row, min(columns, n-i*columns))...)
+	}
+	return result
+}
+
+// Divides the area into n columns of equal width, the last one takes the remainder
+func splitColumns(area rect, n int) []rect {
+	result := make([]rect, n)
+	width := area.width / n
+	for i := range result {
+		result[i] = rect{area.x + i*width, area.y, width, area.height}
+	}
+	result[n-1].width = area.width - (n-1)*width
+	return result
+}
+
+// Divides the area into n rows of equal height, the last one takes the remainder
+func splitRows(area rect, n int) []rect {
+	result := make([]rect, n)
+	height := area.height / n
+	for i := range result {
+		result[i] = rect{area.x, area.y + i*height, area.width, height}
+	}
+	result[n-1].height = area.height - (n-1)*height
+	return result
+}
+
+// Returns the panes shown in the main area: the pinned panes in the order
+// they were pinned, followed by the selected pane if it is not pinned
+func (ui *UI) visiblePanes() []*pane {
+	selected := ui.selectedPane()
+	if ui.layout == layoutSingle {
+		if selected == nil {
+			return nil
+		}
+		return []*pane{selected}
+	}
+
+	result := []*pane{}
+	for _, key := range ui.splits {
+		for _, p := range ui.panes {
+			if p.key == key {
+				result = append(result, p)
+			}
+		}
+	}
+	if selected != nil && !slices.Contains(ui.splits, selected.key) {
+		result = append(result, selected)
+	}
+	return result
+}
+
+// Returns true if the pane is shown in the main area
+func (ui *UI) isVisible(p *pane) bool {
+	return slices.Contains(ui.visiblePanes(), p)
+}
+
+// Returns the visible pane at the given screen coordinates
+func (ui *UI) paneAt(x int, y int) *pane {
+	for _, p := range ui.visiblePanes() {
+		if p.area.contains(x, y) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Pins the selected pane to the split layouts or unpins it.
+// Pinning switches from the single layout to side by side panes.
+func (ui *UI) toggleSplit() {
+	selected := ui.selectedPane()
+	if selected == nil {
+		return
+	}
+
+	if index := slices.Index(ui.splits, selected.key); index >= 0 {
+		ui.splits = slices.Delete(ui.splits, index, index+1)
+	} else {
+		ui.splits = append(ui.splits, selected.key)
+		if ui.layout == layoutSingle {
+			ui.layout = layoutHorizontal
+		}
+	}
+	ui.draw()
+	ui.screen.Sync()
+}
+
+// Switches to the next layout
+func (ui *UI) cycleLayout() {
+	ui.layout = ui.layout.next()
+	ui.draw()
+	ui.screen.Sync()
+}
+
+// Selects the next visible pane, moving the focus between splits
+func (ui *UI) cycleSplit() {
+	visible := ui.visiblePanes()
+	if len(visible) < 2 {
+		return
+	}
+
+	index := slices.Index(visible, ui.selectedPane())
+	ui.selected = visible[(index+1)%len(visible)].key
+	ui.checkFocus()
+	ui.draw()
+}
+
+// Assigns an area to every pane and resizes its terminal accordingly.
+// Hidden panes take the whole main area, as if they were shown alone.
+func (ui *UI) arrange() {
+	area := ui.mainArea()
+
+	visible := ui.visiblePanes()
+	areas := ui.layout.split(area, len(visible))
+	for _, p := range ui.panes {
+		index := slices.Index(visible, p)
+		switch {
+		case index < 0:
+			p.setArea(area, area)
+		case ui.layout == layoutSingle:
+			p.setArea(areas[index], areas[index])
+		default:
+			p.setArea(areas[index], areas[index].inner())
+		}
+	}
+}
+
+// Draws the border of the pane with its title, highlighting the selected one
+func (ui *UI) drawBorder(p *pane, selected bool) {
+	style := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	if selected {
+		style = tcell.StyleDefault.Foreground(tcell.ColorOrange).Bold(!ui.focused)
+	}
+
+	area := p.area
+	right := area.x + area.width - 1
+	bottom := area.y + area.height - 1
+	for x := area.x + 1; x < right; x++ {
+		ui.screen.SetContent(x, area.y, '─', nil, style)
+		ui.screen.SetContent(x, bottom, '─', nil, style)
+	}
+	for y := area.y + 1; y < bottom; y++ {
+		ui.screen.SetContent(area.x, y, '│', nil, style)
+		ui.screen.SetContent(right, y, '│', nil, style)
+	}
+	ui.screen.SetContent(area.x, area.y, '┌', nil, style)
+	ui.screen.SetContent(right, area.y, '┐', nil, style)
+	ui.screen.SetContent(area.x, bottom, '└', nil, style)
+	ui.screen.SetContent(right, bottom, '┘', nil, style)
+
+	// Title in the top border, truncated to fit between the corners
+	title := []rune(" " + p.title + " ")
+	if len(title) > area.width-4 {
+		title = title[:max(area.width-4, 0)]
+	}
+	for i, r := range title {
+		ui.screen.SetContent(area.x+2+i, area.y, r, nil, style)
+	}
+}
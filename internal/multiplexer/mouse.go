@@ -0,0 +1,59 @@
+package multiplexer
+
+import (
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Mouse events the multiplexer needs for its own selection
+const defaultMouseFlags = tcell.MouseButtonEvents | tcell.MouseDragEvents
+
+// Sends the mouse event to the process of the focused pane if it tracks the
+// mouse, with the position relative to its terminal. Once a button was
+// pressed in the pane, the drag and the release go to the pane as well.
+// Holding shift keeps the multiplexer selection. Returns false if the
+// multiplexer handles the event itself.
+func (ui *UI) forwardMouse(evt *tcell.EventMouse) bool {
+	selected := ui.selectedPane()
+	if selected == nil || !ui.focused || selected.dead || ui.dragging || ui.copying != nil {
+		ui.capturing = false
+		return false
+	}
+
+	x, y := evt.Position()
+	area := selected.content
+	buttons := evt.Buttons()
+	wheel := buttons&(tcell.WheelUp|tcell.WheelDown|tcell.WheelLeft|tcell.WheelRight) != 0
+	switch {
+	case ui.capturing:
+	case !area.contains(x, y) || evt.Modifiers()&tcell.ModShift != 0:
+		return false
+	case len(selected.vt.MouseFlags()) > 0:
+	case wheel && selected.vt.AlternateScroll():
+	default:
+		return false
+	}
+
+	ui.capturing = buttons&(tcell.ButtonPrimary|tcell.ButtonSecondary|tcell.ButtonMiddle) != 0
+	col := min(max(x-area.x, 0), area.width-1)
+	row := min(max(y-area.y, 0), area.height-1)
+	selected.vt.HandleEvent(tcell.NewEventMouse(col, row, buttons, evt.Modifiers()))
+	return true
+}
+
+// Enables the mouse events needed by the multiplexer, and the motion events
+// when the focused process tracks all mouse motion
+func (ui *UI) updateMouse() {
+	flags := defaultMouseFlags
+	if selected := ui.selectedPane(); selected != nil && ui.focused {
+		if slices.Contains(selected.vt.MouseFlags(), tcell.MouseMotionEvents) {
+			flags |= tcell.MouseMotionEvents
+		}
+	}
+
+	if flags != ui.mouseFlags {
+		ui.mouseFlags = flags
+		ui.screen.EnableMouse(flags)
+	}
+}
@@ -2,12 +2,15 @@ package multiplexer
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
+	"log/slog"
+	"net"
 	"os"
-	"strings"
+	"slices"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/views"
+	"github.com/nodge/multiplexer/internal/clipboard"
 	"github.com/nodge/multiplexer/internal/process"
 	tcellterm "github.com/nodge/multiplexer/internal/tcell-term"
 )
@@ -17,6 +20,9 @@ type Multiplexer struct {
 	panes     []*pane
 	ui        *UI
 	eventLoop *EventLoop
+	listener  net.Listener // control socket, nil when not listening
+	detach    func()       // detaches the client from the session, nil when not detachable
+	clipboard clipboard.Clipboard
 }
 
 func New(ctx context.Context) (*Multiplexer, error) {
@@ -25,12 +31,28 @@ func New(ctx context.Context) (*Multiplexer, error) {
 		return nil, err
 	}
 
-	err = screen.Init()
+	return newWithScreen(ctx, screen)
+}
+
+// NewWithTty creates a multiplexer rendering into the given tty instead of the
+// controlling terminal, e.g. a session tty that clients attach to
+func NewWithTty(ctx context.Context, tty tcell.Tty) (*Multiplexer, error) {
+	screen, err := tcell.NewTerminfoScreenFromTty(tty)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWithScreen(ctx, screen)
+}
+
+func newWithScreen(ctx context.Context, screen tcell.Screen) (*Multiplexer, error) {
+	err := screen.Init()
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize screen: %w", err)
 	}
 
-	screen.EnableMouse()
+	screen.EnableMouse(defaultMouseFlags)
+	screen.EnablePaste()
 	screen.Show()
 
 	result := &Multiplexer{
@@ -39,6 +61,7 @@ func New(ctx context.Context) (*Multiplexer, error) {
 		ui:    NewUI(screen),
 	}
 
+	result.setClipboard(clipboard.Options{Backend: clipboard.Auto})
 	result.enableTmuxClipboard()
 
 	return result, nil
@@ -49,7 +72,14 @@ func New(ctx context.Context) (*Multiplexer, error) {
 // The loop continues until the context is cancelled or an exit event is received.
 func (s *Multiplexer) Start() {
 	defer func() {
+		if s.listener != nil {
+			s.listener.Close()
+		}
 		s.ui.stop()
+		// Removes the scrollback spilled to temporary files
+		for _, p := range s.panes {
+			p.vt.ClearScrollback()
+		}
 	}()
 
 	s.ui.start()
@@ -63,34 +93,79 @@ func (s *Multiplexer) Exit() {
 	s.ui.screen.PostEvent(&EventExit{})
 }
 
+// EnableDetach makes the multiplexer detachable with a hotkey.
+// The function is called right before the screen is suspended and asks the tty
+// to let the client go when the screen stops.
+func (s *Multiplexer) EnableDetach(detach func()) {
+	s.detach = detach
+	s.ui.detachable = detach != nil
+}
+
+// Reengage posts an event that suspends the screen, runs fn, resumes the
+// screen and redraws it. It is used to switch the client of a session tty.
+func (s *Multiplexer) Reengage(fn func()) {
+	s.ui.screen.PostEvent(&EventReengage{fn: fn})
+}
+
+// Suspends the screen, runs fn and resumes the screen, must be called from the main event loop
+func (s *Multiplexer) reengage(fn func()) {
+	s.ui.screen.Suspend()
+	fn()
+	s.ui.screen.Resume()
+
+	s.resize(s.ui.screen.Size())
+	s.ui.draw()
+	s.ui.screen.Sync()
+}
+
+// SetKeybindings posts an event that replaces the key bindings, the given
+// bindings are merged into the defaults and ActionNone removes a binding
+func (s *Multiplexer) SetKeybindings(keybindings Keybindings) {
+	s.ui.screen.PostEvent(&EventKeybindings{Keybindings: keybindings})
+}
+
 // AddProcess posts an event to add a new process to the multiplexer
-func (s *Multiplexer) AddProcess(key string, cmd []string, env map[string]string, title string, cwd string, killable bool, autostart bool) {
-	s.ui.screen.PostEvent(&EventProcess{
-		Key:       key,
-		Cmd:       cmd,
-		Env:       env,
-		Title:     title,
-		Cwd:       cwd,
-		Killable:  killable,
-		Autostart: autostart,
-	})
+func (s *Multiplexer) AddProcess(proc EventProcess) {
+	s.ui.screen.PostEvent(&proc)
+}
+
+// Posts an event to the main event loop
+func (s *Multiplexer) postEvent(ev tcell.Event) {
+	s.ui.screen.PostEvent(ev)
 }
 
 // Creates new pane and attaches virtual terminal
 func (s *Multiplexer) addPane(p *pane) *pane {
 	p.vt = tcellterm.New()
-	p.vt.SetSurface(s.ui.activePaneView)
+	p.view = views.NewViewPort(s.ui.screen, 0, 0, 0, 0)
+	p.vt.SetSurface(p.view)
 	// Forward terminal events back to the main event loop
-	p.vt.Attach(func(ev tcell.Event) {
-		s.ui.screen.PostEvent(ev)
-	})
+	p.vt.Attach(s.postEvent)
 
 	s.panes = append(s.panes, p)
+	// Sizes the terminal up front, panes waiting for dependencies are drawn
+	// before their process is started
 	s.ui.addPane(p)
 
 	return p
 }
 
+// Stops the process of the pane and removes the pane with its scrollback and log file
+func (s *Multiplexer) removePane(p *pane) {
+	s.unschedule(p)
+	p.stopProbe()
+	if !p.dead {
+		p.kill()
+	}
+	// Events of the terminal are no longer handled
+	p.vt.Detach()
+	p.vt.ClearScrollback()
+	p.closeLog()
+
+	s.panes = slices.DeleteFunc(s.panes, func(other *pane) bool { return other == p })
+	s.ui.removePane(p)
+}
+
 // resize delegates to the UI's Resize method
 func (s *Multiplexer) resize(width int, height int) {
 	s.ui.resize(width, height)
@@ -117,9 +192,8 @@ func (s *Multiplexer) scrollUp(n int) {
 	s.ui.draw()
 }
 
-// Handles clipboard operations for selected text.
-// Uses platform-specific methods: pbcopy on macOS Terminal, OSC 52 escape sequences elsewhere.
-// OSC 52 allows terminal applications to set clipboard content via escape sequences.
+// Copies the selected text of the selected pane to the clipboard, must be
+// called from the main event loop
 func (s *Multiplexer) copy() {
 	selected := s.ui.selectedPane()
 	if selected == nil {
@@ -131,20 +205,9 @@ func (s *Multiplexer) copy() {
 		return
 	}
 
-	// Use pbcopy on macOS Terminal for better integration
-	if os.Getenv("TERM_PROGRAM") == "Apple_Terminal" {
-		cmd := process.Command("pbcopy")
-		cmd.Stdin = strings.NewReader(data)
-		err := cmd.Run()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to copy to clipboard: %v\n", err)
-		}
-		return
+	if err := s.clipboard.Copy(data); err != nil {
+		slog.Error("failed to copy to clipboard", "err", err)
 	}
-
-	// Use OSC 52 escape sequence for universal clipboard support
-	encoded := base64.StdEncoding.EncodeToString([]byte(data))
-	fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\x07", encoded)
 }
 
 // Enables clipboard integration when the multiplexer is running within a tmux session.
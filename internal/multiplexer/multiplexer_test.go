@@ -0,0 +1,67 @@
+//go:build !windows
+// +build !windows
+
+package multiplexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/process"
+)
+
+// Creates a multiplexer drawing on a simulated screen, the processes of its
+// panes are killed when the test ends
+func newTestMultiplexer(t *testing.T) *EventLoop {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := newWithScreen(ctx, tcell.NewSimulationScreen(""))
+	if err != nil {
+		t.Fatalf("newWithScreen() error = %v", err)
+	}
+	s.resize(s.ui.screen.Size())
+	t.Cleanup(func() {
+		cancel()
+		for _, p := range s.panes {
+			if p.cmd != nil {
+				process.Kill(p.cmd)
+			}
+			p.closeLog()
+		}
+		s.ui.screen.Fini()
+	})
+
+	return NewEventLoop(s)
+}
+
+// Adds a pane running the shell script
+func addTestPane(eh *EventLoop, key string, script string) *pane {
+	eh.handleProcessEvent(testProcess(key, script))
+	return eh.multiplexer.findPane(key)
+}
+
+// Returns the process event of a pane running the shell script
+func testProcess(key string, script string) *EventProcess {
+	return &EventProcess{
+		Key:       key,
+		Cmd:       []string{"/bin/sh", "-c", script},
+		Title:     "→ " + key,
+		Killable:  true,
+		Autostart: true,
+	}
+}
+
+// Waits until the condition holds, or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for range 250 {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
@@ -0,0 +1,274 @@
+package multiplexer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/shellwords"
+)
+
+// Pseudo actions shown in the hotkeys while the new pane prompt is open
+const (
+	actionPromptAccept  = "prompt-accept"
+	actionPromptCancel  = "prompt-cancel"
+	actionPromptHistory = "prompt-history"
+)
+
+// Keys of the new pane prompt shown in the hotkeys
+var newPaneHotkeys = map[string]string{
+	"enter": actionPromptAccept,
+	"esc":   actionPromptCancel,
+	"up":    actionPromptHistory,
+	"down":  actionPromptHistory,
+}
+
+// Fields asked by the new pane prompt, in order
+type promptField int
+
+const (
+	fieldCommand promptField = iota
+	fieldName
+	fieldCwd
+)
+
+// Labels of the fields shown in front of the input
+var promptLabels = map[promptField]string{
+	fieldCommand: "command: ",
+	fieldName:    "name: ",
+	fieldCwd:     "cwd: ",
+}
+
+// Characters replaced in names derived from commands
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Command line, name and working directory being typed for a new pane
+type newPanePrompt struct {
+	field   promptField
+	input   []rune
+	err     string // why the input was rejected, shown next to it
+	history int    // index of the history entry shown, len(history) for the typed line
+	draft   []rune // typed line kept while browsing the history
+
+	line string   // accepted command line
+	args []string // words of the command line
+	name string   // accepted name
+	cwd  string   // directory relative working directories are resolved against
+}
+
+// Opens the prompt for the command line of a new pane
+func (ui *UI) startNewPane() bool {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "/"
+	}
+
+	ui.creating = &newPanePrompt{history: len(ui.history), cwd: cwd}
+	ui.draw()
+	return true
+}
+
+// Handles keyboard input while the new pane prompt is open
+func (eh *EventLoop) handleNewPaneKey(evt *tcell.EventKey) {
+	prompt := eh.ui.creating
+
+	switch evt.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		eh.ui.creating = nil
+
+	case tcell.KeyEnter:
+		eh.acceptNewPaneField()
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(prompt.input) > 0 {
+			prompt.input = prompt.input[:len(prompt.input)-1]
+		}
+		prompt.err = ""
+
+	case tcell.KeyCtrlU:
+		prompt.input = nil
+		prompt.err = ""
+
+	case tcell.KeyUp, tcell.KeyDown:
+		if prompt.field == fieldCommand {
+			prompt.browse(eh.ui.history, evt.Key() == tcell.KeyUp)
+		}
+
+	case tcell.KeyRune:
+		prompt.input = append(prompt.input, evt.Rune())
+		prompt.err = ""
+	}
+
+	eh.ui.draw()
+}
+
+// Moves through the history of command lines, the typed line is kept as the
+// entry after the newest one
+func (p *newPanePrompt) browse(history []string, older bool) {
+	index := p.history + 1
+	if older {
+		index = p.history - 1
+	}
+	if index < 0 || index > len(history) {
+		return
+	}
+
+	if p.history == len(history) {
+		p.draft = p.input
+	}
+	p.history = index
+	p.input = p.draft
+	if index < len(history) {
+		p.input = []rune(history[index])
+	}
+	p.err = ""
+}
+
+// Adds text to the input of the prompt, pasted line breaks are dropped
+func (p *newPanePrompt) paste(text string) {
+	for _, r := range text {
+		if !unicode.IsControl(r) {
+			p.input = append(p.input, r)
+		}
+	}
+	p.err = ""
+}
+
+// Checks the input of the current field and moves to the next one, the new
+// pane is added once the working directory is accepted
+func (eh *EventLoop) acceptNewPaneField() {
+	prompt := eh.ui.creating
+	input := strings.TrimSpace(string(prompt.input))
+
+	switch prompt.field {
+	case fieldCommand:
+		args, err := shellwords.Split(input)
+		if err != nil {
+			prompt.err = err.Error()
+			return
+		}
+		if len(args) == 0 {
+			prompt.err = "command is empty"
+			return
+		}
+		prompt.line = input
+		prompt.args = args
+		prompt.field = fieldName
+		prompt.input = []rune(eh.multiplexer.uniqueName(args[0], nil))
+
+	case fieldName:
+		if input == "" {
+			input = eh.multiplexer.uniqueName(prompt.args[0], nil)
+		}
+		if invalidNameChars.MatchString(input) {
+			prompt.err = "use letters, digits, - and _"
+			return
+		}
+		if eh.multiplexer.findPane(input) != nil {
+			prompt.err = fmt.Sprintf("'%s' is taken", input)
+			return
+		}
+		prompt.name = input
+		prompt.field = fieldCwd
+		prompt.input = []rune(prompt.cwd)
+
+	case fieldCwd:
+		cwd := prompt.cwd
+		if input != "" {
+			cwd = resolvePath(input, prompt.cwd)
+		}
+		if info, err := os.Stat(cwd); err != nil || !info.IsDir() {
+			prompt.err = "not a directory"
+			return
+		}
+
+		eh.ui.creating = nil
+		eh.ui.addHistory(prompt.line)
+		eh.multiplexer.postEvent(&EventProcess{
+			Key:       prompt.name,
+			Cmd:       prompt.args,
+			Title:     "→ " + prompt.name,
+			Cwd:       cwd,
+			Killable:  true,
+			Autostart: true,
+			Select:    true,
+			AdHoc:     true,
+		})
+	}
+}
+
+// Adds the command line to the history unless it repeats the newest entry
+func (ui *UI) addHistory(line string) {
+	if len(ui.history) > 0 && ui.history[len(ui.history)-1] == line {
+		return
+	}
+	ui.history = append(ui.history, line)
+}
+
+// Returns a pane name derived from the command that no pane uses yet, nor
+// one of the reserved names, e.g. `go`, `go-2`, `go-3`
+func (s *Multiplexer) uniqueName(command string, reserved map[string]bool) string {
+	base := strings.Trim(invalidNameChars.ReplaceAllString(filepath.Base(command), "-"), "-")
+	if base == "" {
+		base = "cmd"
+	}
+
+	name := base
+	for i := 2; s.findPane(name) != nil || reserved[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// Resolves a path typed in the prompt against the directory, expanding a leading ~
+func resolvePath(path string, dir string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// Draws the new pane prompt over the bottom line of the main area
+func (ui *UI) drawNewPanePrompt() {
+	prompt := ui.creating
+	if prompt == nil {
+		return
+	}
+
+	area := ui.mainArea()
+	y := area.y + area.height - 1
+	line := []rune(promptLabels[prompt.field] + string(prompt.input))
+	cursor := area.x + len(line)
+
+	labelStyle := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	labelLength := len([]rune(promptLabels[prompt.field]))
+	for x := 0; x < area.width; x++ {
+		r, style := ' ', tcell.StyleDefault
+		if x < len(line) {
+			r = line[x]
+		}
+		if x < labelLength {
+			style = labelStyle
+		}
+		ui.screen.SetContent(area.x+x, y, r, nil, style)
+	}
+
+	errStyle := tcell.StyleDefault.Foreground(tcell.ColorRed)
+	for i, r := range []rune(prompt.err) {
+		x := area.width - len([]rune(prompt.err)) - 1 + i
+		if x > len(line) {
+			ui.screen.SetContent(area.x+x, y, r, nil, errStyle)
+		}
+	}
+
+	ui.screen.ShowCursor(min(cursor, area.x+area.width-1), y)
+}
@@ -1,12 +1,24 @@
 package multiplexer
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"sync"
+	"time"
 
+	"github.com/gdamore/tcell/v2/views"
+	"github.com/nodge/multiplexer/internal/logfile"
 	"github.com/nodge/multiplexer/internal/process"
 	tcellterm "github.com/nodge/multiplexer/internal/tcell-term"
 )
 
+// Scrollback limits the output history kept for a pane
+type Scrollback struct {
+	Lines int // lines kept in memory, zero uses the default
+	Spill int // older lines compressed to temporary files instead of being dropped
+}
+
 // Pane represents a terminal process with its associated state and virtual terminal
 type pane struct {
 	key      string
@@ -16,26 +28,79 @@ type pane struct {
 	args     []string
 	env      map[string]string
 	killable bool
+	adHoc    bool // started from the new pane prompt, kept on reloads
 	vt       *tcellterm.VT
+	view     *views.ViewPort // part of the screen the terminal is drawn on
+	area     rect            // screen area of the pane including its border
+	content  rect            // screen area of the terminal
 	dead     bool
+	exit     *exitStatus // how the last run ended, nil while running or never started
+
+	// Dependency scheduling state
+	dependsOn []string // keys of panes that must be running before this one starts
+	waiting   bool     // true while the pane is held back by its dependencies
+	blockedBy []string // dependencies that are not running yet
+
+	// Readiness probe state
+	ready     *ReadyProbe
+	readiness readiness
+	probe     *probe
+
+	// Restart policy state
+	restart        *RestartPolicy
+	restarts       int             // number of consecutive restarts
+	pendingRestart *pendingRestart // restart waiting for its backoff delay
+	stopping       bool            // true when the process was killed manually
+	relaunch       bool            // true when the killed process is started again once it exited
+	startedAt      time.Time
+
+	stop *process.StopOptions // how the process is stopped, nil for the defaults
+
+	// Log file state
+	logOptions *logfile.Options
+	log        *logfile.Writer // opened on the first start and kept open across restarts
+	output     logOutput       // output of the process, written to the log file
+}
+
+// logOutput writes the output of the pane process to the current log file of
+// the pane, the file can change while the process runs
+type logOutput struct {
+	mu  sync.Mutex
+	log *logfile.Writer
+}
+
+// Writes the output to the log file, or discards it without one
+func (o *logOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.log == nil {
+		return len(p), nil
+	}
+	return o.log.Write(p)
+}
+
+// Switches to another log file, nil discards the output
+func (o *logOutput) set(log *logfile.Writer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.log = log
 }
 
 // Initializes and starts the terminal process for this pane
 func (p *pane) start() error {
 	p.cmd = process.Command(p.args[0], p.args[1:]...)
 
-	// Convert environment map to slice format required by exec.Cmd
-	if p.env != nil {
-		env := make([]string, 0, len(p.env))
-		for key, value := range p.env {
-			env = append(env, key+"="+value)
-		}
-		p.cmd.Env = env
-	}
+	p.cmd.Env = p.environ()
 
 	if p.dir != "" {
 		p.cmd.Dir = p.dir
 	}
+	p.applyStop()
+
+	if err := p.openLog(); err != nil {
+		return err
+	}
+	p.vt.Output = &p.output
 
 	p.vt.Clear()
 
@@ -45,13 +110,87 @@ func (p *pane) start() error {
 	}
 
 	p.dead = false
+	p.exit = nil
+	p.stopping = false
+	p.startedAt = time.Now()
 
 	return nil
 }
 
-// Terminates the terminal process for this pane
+// Shows a message in the terminal of the pane, the message is not part of
+// the output of the command and is left out of its log file
+func (p *pane) showMessage(text string) {
+	output := p.vt.Output
+	p.vt.Output = nil
+	p.vt.Start(process.Command("echo", text))
+	p.vt.Output = output
+}
+
+// Returns the environment of the multiplexer with the variables of the pane
+// on top, exec.Cmd uses the last value of duplicate keys
+func (p *pane) environ() []string {
+	env := os.Environ()
+	for key, value := range p.env {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
+
+// Registers how the process of the pane is stopped, the stop command runs in
+// the directory and environment of the pane
+func (p *pane) applyStop() {
+	if p.cmd == nil {
+		return
+	}
+	var stop process.StopOptions
+	if p.stop != nil {
+		stop = *p.stop
+	}
+	stop.Dir = p.cmd.Dir
+	stop.Env = p.cmd.Env
+	process.SetStop(p.cmd, stop)
+}
+
+// Terminates the terminal process for this pane in the background, the
+// closed event of the terminal reports when it is gone
 func (p *pane) kill() {
-	p.vt.Close()
+	p.stopping = true
+	p.relaunch = false
+	p.vt.Stop()
+}
+
+// Opens the log file unless it is open already
+func (p *pane) openLog() error {
+	if p.logOptions == nil || p.log != nil {
+		return nil
+	}
+	log, err := logfile.Open(*p.logOptions)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	p.log = log
+	p.output.set(log)
+	return nil
+}
+
+// Closes the log file, the next start opens it again
+func (p *pane) closeLog() {
+	if p.log == nil {
+		return
+	}
+	p.output.set(nil)
+	p.log.Close()
+	p.log = nil
+}
+
+// Places the pane on the screen, resizing the terminal when its size changes
+func (p *pane) setArea(area rect, content rect) {
+	p.area = area
+	p.view.Resize(content.x, content.y, content.width, content.height)
+	if p.content.width != content.width || p.content.height != content.height {
+		p.vt.Resize(content.width, content.height)
+	}
+	p.content = content
 }
 
 // Scrolls the terminal view up by the specified offset
@@ -69,6 +208,14 @@ func (p *pane) scrollReset() {
 	p.vt.ScrollReset()
 }
 
+// Returns true if the process is running and ready, and can satisfy dependencies
+func (p *pane) isRunning() bool {
+	if p.dead {
+		return false
+	}
+	return p.readiness == readinessNone || p.readiness == readinessReady
+}
+
 // Returns true if the terminal is currently scrolled up from the bottom
 func (p *pane) isScrolling() bool {
 	return p.vt.IsScrolling()
@@ -0,0 +1,69 @@
+package multiplexer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Handles the start and the end of a bracketed paste, the keys in between
+// are collected by handleKeyEvent and pasted at once when the paste ends
+func (eh *EventLoop) handlePasteEvent(evt *tcell.EventPaste) {
+	if evt.Start() {
+		eh.ui.pasting = &strings.Builder{}
+		return
+	}
+
+	if eh.ui.pasting == nil {
+		return
+	}
+	text := eh.ui.pasting.String()
+	eh.ui.pasting = nil
+	eh.paste(text)
+}
+
+// Pastes text into the open prompt or the focused terminal
+func (eh *EventLoop) paste(text string) {
+	if text == "" {
+		return
+	}
+
+	if prompt := eh.ui.creating; prompt != nil {
+		prompt.paste(text)
+		eh.ui.draw()
+		return
+	}
+
+	if prompt := eh.ui.search; prompt != nil {
+		for _, r := range text {
+			if !unicode.IsControl(r) {
+				prompt.pattern = append(prompt.pattern, r)
+			}
+		}
+		prompt.update()
+		eh.ui.draw()
+		eh.ui.screen.Sync()
+		return
+	}
+
+	selected := eh.ui.selectedPane()
+	if eh.ui.copying != nil || selected == nil || !eh.ui.focused || selected.isScrolling() {
+		return
+	}
+	selected.vt.Paste(text)
+	eh.ui.draw()
+}
+
+// Returns the text of a key received during a paste
+func pasteKey(evt *tcell.EventKey) string {
+	switch evt.Key() {
+	case tcell.KeyRune:
+		return string(evt.Rune())
+	case tcell.KeyEnter, tcell.KeyLF:
+		return "\n"
+	case tcell.KeyTab:
+		return "\t"
+	}
+	return ""
+}
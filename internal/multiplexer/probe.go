@@ -0,0 +1,178 @@
+package multiplexer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/process"
+)
+
+// ReadyProbe describes how to check that a started process is ready.
+// Exactly one of TCP, HTTP, Log or Exec is expected to be set.
+type ReadyProbe struct {
+	TCP      string         // address to connect to
+	HTTP     string         // URL that must answer a GET request
+	Status   int            // expected HTTP status, any 2xx when zero
+	Log      *regexp.Regexp // pattern matched against the pane output
+	Exec     []string       // command that must exit with status 0
+	Interval time.Duration  // delay between checks
+	Timeout  time.Duration  // time after which the probe fails, zero waits forever
+}
+
+// Readiness state of a pane process
+type readiness int
+
+const (
+	readinessNone     readiness = iota // no probe configured
+	readinessStarting                  // probe is running
+	readinessReady                     // probe succeeded
+	readinessFailed                    // probe timed out
+)
+
+// EventReadiness is posted when the readiness probe of a pane succeeds or fails
+type EventReadiness struct {
+	tcell.EventTime
+	Key   string
+	Ready bool
+
+	probe *probe
+}
+
+// A running readiness probe for a single process start
+type probe struct {
+	spec   *ReadyProbe
+	cancel context.CancelFunc
+}
+
+// Starts the readiness probe of the pane, results are posted as EventReadiness
+func (p *pane) startProbe(ctx context.Context, post func(tcell.Event)) {
+	p.stopProbe()
+
+	if p.ready == nil {
+		p.readiness = readinessNone
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	pr := &probe{spec: p.ready, cancel: cancel}
+	p.probe = pr
+	p.readiness = readinessStarting
+
+	check := pr.checker(p)
+	go func() {
+		defer cancel()
+		ready := pr.run(ctx, check)
+		if ctx.Err() != nil {
+			return
+		}
+		post(&EventReadiness{Key: p.key, Ready: ready, probe: pr})
+	}()
+}
+
+// Stops the readiness probe of the pane if one is running
+func (p *pane) stopProbe() {
+	if p.probe != nil {
+		p.probe.cancel()
+		p.probe = nil
+	}
+}
+
+// Polls the check until it succeeds, the probe times out or the context is cancelled
+func (pr *probe) run(ctx context.Context, check func(ctx context.Context) error) bool {
+	interval := pr.spec.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var timeout <-chan time.Time
+	if pr.spec.Timeout > 0 {
+		timeout = time.After(pr.spec.Timeout)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		attempt, cancel := context.WithTimeout(ctx, interval)
+		err := check(attempt)
+		cancel()
+		if err == nil {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timeout:
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns the check function matching the probe type
+func (pr *probe) checker(p *pane) func(ctx context.Context) error {
+	spec := pr.spec
+
+	switch {
+	case spec.TCP != "":
+		return func(ctx context.Context) error {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", spec.TCP)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+
+	case spec.HTTP != "":
+		return func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, spec.HTTP, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if spec.Status != 0 && resp.StatusCode != spec.Status {
+				return fmt.Errorf("unexpected status %d", resp.StatusCode)
+			}
+			if spec.Status == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+				return fmt.Errorf("unexpected status %d", resp.StatusCode)
+			}
+			return nil
+		}
+
+	case spec.Log != nil:
+		// Only output produced since this start matches, the scrollback
+		// survives restarts
+		vt := p.vt
+		return func(ctx context.Context) error {
+			if !vt.Match(spec.Log) {
+				return fmt.Errorf("no output matching %q", spec.Log)
+			}
+			return nil
+		}
+
+	case len(spec.Exec) > 0:
+		dir := p.dir
+		env := p.environ()
+		return func(ctx context.Context) error {
+			cmd := process.CommandContext(ctx, spec.Exec[0], spec.Exec[1:]...)
+			cmd.Dir = dir
+			cmd.Env = env
+			return cmd.Run()
+		}
+	}
+
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
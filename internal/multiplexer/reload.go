@@ -0,0 +1,155 @@
+package multiplexer
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/nodge/multiplexer/internal/logfile"
+)
+
+// EventReload is a custom event used to replace the processes after the
+// configuration changed, or to report why the new configuration was rejected
+type EventReload struct {
+	tcell.EventTime
+	Processes []EventProcess
+	Err       error
+}
+
+// Reload posts an event that brings the panes in line with the processes:
+// new processes are added, missing ones are removed, and panes whose command,
+// environment or working directory changed are restarted. The other panes,
+// and the panes started from the new pane prompt, keep running with their
+// scrollback.
+func (s *Multiplexer) Reload(processes []EventProcess) {
+	s.ui.screen.PostEvent(&EventReload{Processes: processes})
+}
+
+// ReloadFailed posts an event that shows why the configuration could not be
+// reloaded, the panes are left as they are
+func (s *Multiplexer) ReloadFailed(err error) {
+	s.ui.screen.PostEvent(&EventReload{Err: err})
+}
+
+// Handles EventReload events
+func (eh *EventLoop) handleReloadEvent(evt *EventReload) {
+	defer func() {
+		eh.ui.sort()
+		eh.ui.draw()
+	}()
+
+	if evt.Err != nil {
+		eh.ui.notice = "reload failed: " + evt.Err.Error()
+		return
+	}
+	eh.ui.notice = ""
+
+	keys := make(map[string]bool, len(evt.Processes))
+	for _, proc := range evt.Processes {
+		keys[proc.Key] = true
+	}
+
+	// Panes started from the prompt are not part of the configuration, they
+	// keep running and give up their names to new commands
+	for _, p := range slices.Clone(eh.multiplexer.panes) {
+		switch {
+		case p.adHoc && keys[p.key]:
+			eh.renamePane(p, eh.multiplexer.uniqueName(p.key, keys))
+		case !p.adHoc && !keys[p.key]:
+			eh.multiplexer.removePane(p)
+		}
+	}
+
+	for i := range evt.Processes {
+		proc := &evt.Processes[i]
+		p := eh.multiplexer.findPane(proc.Key)
+		if p == nil {
+			eh.handleProcessEvent(proc)
+			continue
+		}
+		eh.multiplexer.updatePane(p, proc)
+	}
+
+	// Panes waiting for a removed or replaced dependency are checked again
+	eh.multiplexer.startWaiting()
+	eh.ui.checkFocus()
+}
+
+// Renames a pane started from the prompt
+func (eh *EventLoop) renamePane(p *pane, key string) {
+	for i, split := range eh.ui.splits {
+		if split == p.key {
+			eh.ui.splits[i] = key
+		}
+	}
+	if eh.ui.selected == p.key {
+		eh.ui.selected = key
+	}
+	p.key = key
+	p.title = "→ " + key
+}
+
+// Applies the changed settings of a process to its pane, restarting it when
+// the command, the environment or the working directory changed
+func (s *Multiplexer) updatePane(p *pane, proc *EventProcess) {
+	p.title = proc.Title
+	p.killable = proc.Killable
+	p.dependsOn = proc.DependsOn
+	p.ready = proc.Ready
+	p.restart = proc.Restart
+	p.stop = proc.Stop
+	p.applyStop()
+
+	if !sameLogOptions(p.logOptions, proc.Log) {
+		// A running process writes to the new file right away, a stopped
+		// one once it starts
+		p.closeLog()
+		p.logOptions = proc.Log
+		if !p.dead {
+			if err := p.openLog(); err != nil {
+				s.ui.notice = fmt.Sprintf("pane '%s': %v", p.key, err)
+			}
+		}
+	}
+	if proc.Scrollback != nil {
+		p.vt.SetScrollback(proc.Scrollback.Lines, proc.Scrollback.Spill)
+	}
+
+	if slices.Equal(p.args, proc.Cmd) && maps.Equal(p.env, proc.Env) && p.dir == proc.Cwd {
+		return
+	}
+
+	// Only panes that are active or meant to start on their own are restarted
+	active := !p.dead || p.waiting || p.pendingRestart != nil
+	running := !p.dead
+	if running {
+		p.kill()
+	}
+
+	p.args = proc.Cmd
+	p.env = proc.Env
+	p.dir = proc.Cwd
+
+	if !active && !proc.Autostart {
+		return
+	}
+	p.stopProbe()
+	p.readiness = readinessNone
+	p.restarts = 0
+	s.unschedule(p)
+	if running {
+		// Started with the new settings once the process exited
+		p.relaunch = true
+		return
+	}
+	s.schedule(p)
+}
+
+// Returns true if both panes write the same log file in the same way
+func sameLogOptions(a *logfile.Options, b *logfile.Options) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,118 @@
+//go:build !windows
+// +build !windows
+
+package multiplexer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nodge/multiplexer/internal/logfile"
+)
+
+func TestUpdatePane_LogAndScrollback(t *testing.T) {
+	eh := newTestMultiplexer(t)
+	p := addTestPane(eh, "api", "read x; seq 1 100; sleep 60")
+	cmd := p.cmd
+
+	// Only the log file and the scrollback change
+	path := filepath.Join(t.TempDir(), "api.log")
+	proc := testProcess("api", "read x; seq 1 100; sleep 60")
+	proc.Log = &logfile.Options{Path: path}
+	proc.Scrollback = &Scrollback{Lines: 5}
+	eh.multiplexer.updatePane(p, proc)
+
+	if p.cmd != cmd || p.dead || p.stopping {
+		t.Fatal("updatePane() restarted the pane")
+	}
+	if p.log == nil {
+		t.Fatal("updatePane() did not open the new log file")
+	}
+
+	// The running process writes to the new log file and scrollback
+	p.vt.Write([]byte("go\n"))
+	waitFor(t, "the output", func() bool {
+		return strings.Contains(p.vt.String(), "100")
+	})
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "100") {
+		t.Errorf("log file = %q, want the output of the process", data)
+	}
+	if lines := strings.Count(p.vt.Scrollback(), "\n") + 1; lines != 5 {
+		t.Errorf("scrollback has %d lines, want 5", lines)
+	}
+
+	// Removing the log closes the file
+	eh.multiplexer.updatePane(p, testProcess("api", "read x; seq 1 100; sleep 60"))
+	if p.log != nil || p.cmd != cmd {
+		t.Errorf("updatePane() kept the log file or restarted the pane")
+	}
+}
+
+func TestUpdatePane(t *testing.T) {
+	tests := []struct {
+		name        string
+		stopped     bool
+		update      func(proc *EventProcess)
+		wantRestart bool
+	}{
+		{name: "unchanged", update: func(proc *EventProcess) {}},
+		{name: "title", update: func(proc *EventProcess) { proc.Title = "API" }},
+		{name: "restart policy", update: func(proc *EventProcess) { proc.Restart = &RestartPolicy{Policy: RestartAlways} }},
+		{name: "command", update: func(proc *EventProcess) { proc.Cmd[2] = "sleep 30" }, wantRestart: true},
+		{name: "environment", update: func(proc *EventProcess) { proc.Env = map[string]string{"PORT": "80"} }, wantRestart: true},
+		{name: "directory", update: func(proc *EventProcess) { proc.Cwd = t.TempDir() }, wantRestart: true},
+		{name: "stopped pane", stopped: true, update: func(proc *EventProcess) { proc.Cmd[2] = "sleep 30"; proc.Autostart = false }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eh := newTestMultiplexer(t)
+			proc := testProcess("api", "sleep 60")
+			proc.Autostart = !tt.stopped
+			eh.handleProcessEvent(proc)
+			p := eh.multiplexer.findPane("api")
+			cmd := p.cmd
+
+			proc = testProcess("api", "sleep 60")
+			tt.update(proc)
+			eh.multiplexer.updatePane(p, proc)
+
+			if p.title != proc.Title || p.restart != proc.Restart {
+				t.Errorf("updatePane() title = %q, restart = %v, want %q, %v", p.title, p.restart, proc.Title, proc.Restart)
+			}
+			if restarted := p.stopping && p.relaunch; restarted != tt.wantRestart {
+				t.Errorf("updatePane() restarted = %v, want %v", restarted, tt.wantRestart)
+			}
+			if !tt.wantRestart && (p.cmd != cmd || p.dead != tt.stopped) {
+				t.Errorf("updatePane() changed the process of the pane")
+			}
+		})
+	}
+}
+
+func TestReload_AdHocPanes(t *testing.T) {
+	eh := newTestMultiplexer(t)
+	adHoc := testProcess("api", "sleep 60")
+	adHoc.AdHoc = true
+	eh.handleProcessEvent(adHoc)
+	addTestPane(eh, "old", "sleep 60")
+	p := eh.multiplexer.findPane("api")
+
+	// The new command takes the name of the pane started from the prompt,
+	// the command missing from the configuration is removed
+	eh.handleReloadEvent(&EventReload{Processes: []EventProcess{*testProcess("api", "sleep 30")}})
+
+	var keys []string
+	for _, other := range eh.multiplexer.panes {
+		keys = append(keys, other.key)
+	}
+	if len(keys) != 2 || p.key != "api-2" || p.title != "→ api-2" || p.dead {
+		t.Errorf("Reload() panes = %v, prompt pane = %q, want it renamed to api-2 and running", keys, p.key)
+	}
+	if added := eh.multiplexer.findPane("api"); added == nil || added == p || added.dead {
+		t.Error("Reload() did not start the new command")
+	}
+}
@@ -0,0 +1,121 @@
+package multiplexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Restart policies
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// RestartPolicy describes when and how often a pane process is restarted after it exits
+type RestartPolicy struct {
+	Policy     string        // one of RestartNever, RestartOnFailure or RestartAlways
+	MaxRetries int           // maximum number of consecutive restarts, zero means unlimited
+	Backoff    time.Duration // delay before the first restart, doubled on every retry
+	MaxBackoff time.Duration // upper limit of the delay between restarts
+	ResetAfter time.Duration // run time after which the retry count is reset
+}
+
+// EventRestart is posted every second while a restart is pending, and once more when it is due
+type EventRestart struct {
+	tcell.EventTime
+	Key string
+	Due bool
+
+	restart *pendingRestart
+}
+
+// A restart waiting for its backoff delay to pass
+type pendingRestart struct {
+	at     time.Time
+	cancel context.CancelFunc
+}
+
+// Returns true if the process should be restarted according to the policy
+func (p *pane) shouldRestart(failed bool) bool {
+	if p.restart == nil || p.stopping {
+		return false
+	}
+
+	switch p.restart.Policy {
+	case RestartAlways:
+	case RestartOnFailure:
+		if !failed {
+			return false
+		}
+	default:
+		return false
+	}
+
+	// A process that ran long enough starts over with a fresh retry count
+	if time.Since(p.startedAt) >= p.restart.ResetAfter {
+		p.restarts = 0
+	}
+
+	return p.restart.MaxRetries == 0 || p.restarts < p.restart.MaxRetries
+}
+
+// Returns the exponential backoff delay for the next restart
+func (p *pane) restartDelay() time.Duration {
+	delay := p.restart.Backoff
+	for i := 0; i < p.restarts && delay < p.restart.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.restart.MaxBackoff)
+}
+
+// Schedules a restart of the pane after the backoff delay, progress is posted as EventRestart
+func (p *pane) scheduleRestart(ctx context.Context, post func(tcell.Event)) {
+	p.cancelRestart()
+
+	delay := p.restartDelay()
+	p.restarts++
+
+	ctx, cancel := context.WithCancel(ctx)
+	restart := &pendingRestart{at: time.Now().Add(delay), cancel: cancel}
+	p.pendingRestart = restart
+
+	go func() {
+		defer cancel()
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				post(&EventRestart{Key: p.key, restart: restart})
+			case <-timer.C:
+				post(&EventRestart{Key: p.key, Due: true, restart: restart})
+				return
+			}
+		}
+	}()
+}
+
+// Cancels the pending restart of the pane if there is one
+func (p *pane) cancelRestart() {
+	if p.pendingRestart != nil {
+		p.pendingRestart.cancel()
+		p.pendingRestart = nil
+	}
+}
+
+// Returns the time left until the pending restart
+func (p *pane) restartIn() time.Duration {
+	if p.pendingRestart == nil {
+		return 0
+	}
+	return max(time.Until(p.pendingRestart.at), 0)
+}
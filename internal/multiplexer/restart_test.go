@@ -0,0 +1,73 @@
+package multiplexer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *RestartPolicy
+		failed   bool
+		stopping bool
+		restarts int
+		ran      time.Duration
+		want     bool
+	}{
+		{name: "no policy", failed: true},
+		{name: "never", policy: &RestartPolicy{Policy: RestartNever}, failed: true},
+		{name: "on failure after a failure", policy: &RestartPolicy{Policy: RestartOnFailure}, failed: true, want: true},
+		{name: "on failure after a success", policy: &RestartPolicy{Policy: RestartOnFailure}},
+		{name: "always after a success", policy: &RestartPolicy{Policy: RestartAlways}, want: true},
+		{name: "killed manually", policy: &RestartPolicy{Policy: RestartAlways}, stopping: true},
+		{name: "retries left", policy: &RestartPolicy{Policy: RestartAlways, MaxRetries: 3}, restarts: 2, want: true},
+		{name: "retries used up", policy: &RestartPolicy{Policy: RestartAlways, MaxRetries: 3}, restarts: 3},
+		{name: "unlimited retries", policy: &RestartPolicy{Policy: RestartAlways}, restarts: 100, want: true},
+		{
+			name:     "retries reset after a long run",
+			policy:   &RestartPolicy{Policy: RestartAlways, MaxRetries: 3, ResetAfter: time.Minute},
+			restarts: 3,
+			ran:      2 * time.Minute,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pane{
+				restart:   tt.policy,
+				stopping:  tt.stopping,
+				restarts:  tt.restarts,
+				startedAt: time.Now().Add(-tt.ran),
+			}
+			if tt.policy != nil && tt.policy.ResetAfter == 0 {
+				tt.policy.ResetAfter = time.Hour
+			}
+			if got := p.shouldRestart(tt.failed); got != tt.want {
+				t.Errorf("shouldRestart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestartDelay(t *testing.T) {
+	policy := &RestartPolicy{Policy: RestartAlways, Backoff: time.Second, MaxBackoff: 10 * time.Second}
+	tests := []struct {
+		restarts int
+		want     time.Duration
+	}{
+		{restarts: 0, want: time.Second},
+		{restarts: 1, want: 2 * time.Second},
+		{restarts: 3, want: 8 * time.Second},
+		{restarts: 4, want: 10 * time.Second},
+		{restarts: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		p := &pane{restart: policy, restarts: tt.restarts}
+		if got := p.restartDelay(); got != tt.want {
+			t.Errorf("restartDelay() after %d restarts = %v, want %v", tt.restarts, got, tt.want)
+		}
+	}
+}
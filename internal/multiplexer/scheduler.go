@@ -0,0 +1,68 @@
+package multiplexer
+
+// Returns the pane with the given key
+func (s *Multiplexer) findPane(key string) *pane {
+	for _, p := range s.panes {
+		if p.key == key {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Returns the dependencies of the pane that are not running yet
+func (s *Multiplexer) blockers(p *pane) []string {
+	var blockers []string
+	for _, key := range p.dependsOn {
+		dep := s.findPane(key)
+		if dep == nil || !dep.isRunning() {
+			blockers = append(blockers, key)
+		}
+	}
+
+	return blockers
+}
+
+// Starts the pane once all of its dependencies are running.
+// Until then the pane is kept in the waiting state and is started by startWaiting.
+func (s *Multiplexer) schedule(p *pane) {
+	p.blockedBy = s.blockers(p)
+	if len(p.blockedBy) > 0 {
+		p.waiting = true
+		p.dead = true
+		return
+	}
+
+	s.startPane(p)
+}
+
+// Removes the pane from the waiting state and cancels its pending restart without starting it
+func (s *Multiplexer) unschedule(p *pane) {
+	p.waiting = false
+	p.blockedBy = nil
+	p.cancelRestart()
+}
+
+// Starts the pane regardless of its dependencies and wakes up the panes waiting for it.
+// Panes with a readiness probe wake up their dependents once the probe succeeds.
+func (s *Multiplexer) startPane(p *pane) {
+	s.unschedule(p)
+	if err := p.start(); err != nil {
+		// The pane is not running, the panes waiting for it keep waiting
+		p.dead = true
+		p.showMessage("\n[failed to start: " + err.Error() + "]")
+		return
+	}
+	p.startProbe(s.ctx, s.postEvent)
+	s.startWaiting()
+}
+
+// Re-checks the waiting panes and starts the ones whose dependencies are running
+func (s *Multiplexer) startWaiting() {
+	for _, p := range s.panes {
+		if p.waiting {
+			s.schedule(p)
+		}
+	}
+}
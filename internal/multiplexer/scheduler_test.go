@@ -0,0 +1,70 @@
+//go:build !windows
+// +build !windows
+
+package multiplexer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		depCmd      []string // command of the dependency, nil when it is not started
+		wantDepDead bool
+		wantWaiting bool
+	}{
+		{name: "dependency not started", wantDepDead: true, wantWaiting: true},
+		{name: "dependency running", depCmd: []string{"/bin/sh", "-c", "sleep 60"}},
+		{name: "dependency failed to start", depCmd: []string{"/nonexistent/command"}, wantDepDead: true, wantWaiting: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eh := newTestMultiplexer(t)
+			s := eh.multiplexer
+
+			dep := testProcess("db", "sleep 60")
+			dep.Autostart = false
+			eh.handleProcessEvent(dep)
+			api := testProcess("api", "sleep 60")
+			api.DependsOn = []string{"db"}
+			eh.handleProcessEvent(api)
+
+			p := s.findPane("api")
+			if !p.waiting || !p.dead || !slices.Equal(p.blockedBy, []string{"db"}) {
+				t.Fatalf("schedule() waiting = %v, dead = %v, blockedBy = %v, want waiting for db", p.waiting, p.dead, p.blockedBy)
+			}
+
+			if tt.depCmd != nil {
+				db := s.findPane("db")
+				db.args = tt.depCmd
+				s.startPane(db)
+			}
+
+			if db := s.findPane("db"); db.dead != tt.wantDepDead {
+				t.Errorf("startPane() dead = %v, want %v", db.dead, tt.wantDepDead)
+			}
+			if p.waiting != tt.wantWaiting || p.dead != tt.wantWaiting {
+				t.Errorf("startWaiting() waiting = %v, dead = %v, want %v", p.waiting, p.dead, tt.wantWaiting)
+			}
+		})
+	}
+}
+
+func TestStartPane_Failed(t *testing.T) {
+	eh := newTestMultiplexer(t)
+	proc := testProcess("api", "")
+	proc.Cmd = []string{"/nonexistent/command"}
+	eh.handleProcessEvent(proc)
+
+	p := eh.multiplexer.findPane("api")
+	if !p.dead || p.isRunning() {
+		t.Errorf("startPane() dead = %v, isRunning() = %v, want a stopped pane", p.dead, p.isRunning())
+	}
+	waitFor(t, "the error message", func() bool {
+		return strings.Contains(p.vt.String(), "[failed to start:")
+	})
+}
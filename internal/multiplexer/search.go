@@ -0,0 +1,139 @@
+package multiplexer
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Pattern being typed for an incremental search in the scrollback of a pane
+type searchPrompt struct {
+	pane     *pane
+	backward bool // true for searches towards older lines
+	pattern  []rune
+	invalid  bool // true when the pattern is not a valid regular expression
+}
+
+// Opens the search prompt for the selected pane
+func (ui *UI) startSearch(backward bool) bool {
+	selected := ui.selectedPane()
+	if selected == nil {
+		return false
+	}
+
+	// A new search starts from the current view
+	selected.vt.ClearSearch()
+	ui.search = &searchPrompt{pane: selected, backward: backward}
+	ui.draw()
+	return true
+}
+
+// Handles keyboard input while the search prompt is open
+func (eh *EventLoop) handleSearchKey(evt *tcell.EventKey) {
+	prompt := eh.ui.search
+
+	switch evt.Key() {
+	case tcell.KeyEnter:
+		eh.ui.search = nil
+		if len(prompt.pattern) == 0 || prompt.invalid {
+			prompt.pane.vt.AbortSearch()
+		}
+
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		eh.ui.search = nil
+		prompt.pane.vt.AbortSearch()
+
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(prompt.pattern) == 0 {
+			eh.ui.search = nil
+			break
+		}
+		prompt.pattern = prompt.pattern[:len(prompt.pattern)-1]
+		prompt.update()
+
+	case tcell.KeyRune:
+		prompt.pattern = append(prompt.pattern, evt.Rune())
+		prompt.update()
+	}
+
+	eh.ui.draw()
+	eh.ui.screen.Sync()
+}
+
+// Searches for the pattern typed so far
+func (s *searchPrompt) update() {
+	s.invalid = false
+	if len(s.pattern) == 0 {
+		s.pane.vt.AbortSearch()
+		return
+	}
+
+	re, err := compileSearch(string(s.pattern))
+	if err != nil {
+		s.invalid = true
+		return
+	}
+	s.pane.vt.Search(re, s.backward)
+}
+
+// Compiles a search pattern, ignoring case unless it contains upper case letters
+func compileSearch(pattern string) (*regexp.Regexp, error) {
+	for _, r := range pattern {
+		if unicode.IsUpper(r) {
+			return regexp.Compile(pattern)
+		}
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Draws the search prompt over the bottom line of the pane
+func (ui *UI) drawSearchPrompt() {
+	prompt := ui.search
+	if prompt == nil || !ui.isVisible(prompt.pane) {
+		return
+	}
+
+	area := prompt.pane.content
+	y := area.y + area.height - 1
+	style := tcell.StyleDefault
+
+	text := "/" + string(prompt.pattern)
+	if prompt.backward {
+		text = "?" + string(prompt.pattern)
+	}
+	cursor := area.x + len([]rune(text))
+
+	status := ""
+	index, total := prompt.pane.vt.SearchResult()
+	switch {
+	case prompt.invalid:
+		status = "invalid pattern"
+	case len(prompt.pattern) > 0 && total == 0:
+		status = "no match"
+	case len(prompt.pattern) > 0:
+		status = fmt.Sprintf("%d/%d", index, total)
+	}
+
+	line := []rune(text)
+	for x := 0; x < area.width; x++ {
+		r := ' '
+		if x < len(line) {
+			r = line[x]
+		}
+		ui.screen.SetContent(area.x+x, y, r, nil, style)
+	}
+	statusStyle := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	if prompt.invalid {
+		statusStyle = tcell.StyleDefault.Foreground(tcell.ColorRed)
+	}
+	for i, r := range []rune(status) {
+		x := area.width - len([]rune(status)) - 1 + i
+		if x > len(line) {
+			ui.screen.SetContent(area.x+x, y, r, nil, statusStyle)
+		}
+	}
+
+	ui.screen.ShowCursor(min(cursor, area.x+area.width-1), y)
+}
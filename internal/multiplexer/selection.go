@@ -0,0 +1,154 @@
+package multiplexer
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Clicks at the same position within this interval count as a double or triple click
+const multiClickInterval = 500 * time.Millisecond
+
+// Interval between the lines scrolled while a selection is dragged past the edge of a pane
+const autoScrollInterval = 50 * time.Millisecond
+
+// EventAutoScroll is posted repeatedly while a selection is dragged past the
+// top or bottom edge of the selected pane
+type EventAutoScroll struct {
+	tcell.EventTime
+
+	scroller *autoScroll
+}
+
+// Scrolling of a selection dragged past the edge of the pane
+type autoScroll struct {
+	direction int // -1 towards older lines, 1 towards newer lines
+	col       int // column of the pointer in the terminal
+	stop      chan struct{}
+}
+
+// Starts a selection at the click in the selected pane. A double click
+// selects the word and a triple click the line under the pointer.
+func (ui *UI) handleSelection(evt *tcell.EventMouse) {
+	selected := ui.selectedPane()
+	x, y := evt.Position()
+	if selected == nil || !selected.content.contains(x, y) {
+		return
+	}
+
+	clicks := 1
+	if ui.click != nil && evt.When().Sub(ui.click.When()) < multiClickInterval {
+		if oldX, oldY := ui.click.Position(); oldX == x && oldY == y {
+			clicks = ui.clicks%3 + 1
+		}
+	}
+	ui.click = evt
+	ui.clicks = clicks
+	ui.dragging = true
+
+	// Only one selection at a time, the one that is copied
+	for _, p := range ui.panes {
+		if p != selected {
+			p.vt.ClearSelection()
+		}
+	}
+
+	col, row := x-selected.content.x, y-selected.content.y
+	switch ui.clicks {
+	case 1:
+		selected.vt.SelectStart(col, row)
+	case 2:
+		selected.vt.SelectWord(col, row)
+	default:
+		selected.vt.SelectLine(row)
+	}
+	ui.draw()
+}
+
+// Extends the selection to the pointer, scrolling while it is past the top
+// or bottom edge of the pane
+func (ui *UI) dragSelection(x int, y int) {
+	selected := ui.selectedPane()
+	if selected == nil || ui.clicks > 1 {
+		return // word and line selections keep their extent
+	}
+
+	area := selected.content
+	col := min(max(x-area.x, 0), area.width-1)
+	row := min(max(y-area.y, 0), area.height-1)
+	switch {
+	case y < area.y:
+		ui.startAutoScroll(-1, col)
+	case y >= area.y+area.height:
+		ui.startAutoScroll(1, col)
+	default:
+		ui.stopAutoScroll()
+	}
+
+	selected.vt.SelectEnd(col, row)
+	ui.draw()
+}
+
+// Returns true if text was selected by the ongoing drag
+func (ui *UI) hasSelection() bool {
+	selected := ui.selectedPane()
+	return ui.dragging && selected != nil && selected.vt.HasSelection()
+}
+
+// Ends the drag when the mouse button is released
+func (ui *UI) resetDragging() {
+	ui.dragging = false
+	ui.stopAutoScroll()
+}
+
+// Posts EventAutoScroll until the pointer returns into the pane or the button is released
+func (ui *UI) startAutoScroll(direction int, col int) {
+	if ui.scroller != nil && ui.scroller.direction == direction {
+		ui.scroller.col = col
+		return
+	}
+
+	ui.stopAutoScroll()
+	scroller := &autoScroll{direction: direction, col: col, stop: make(chan struct{})}
+	ui.scroller = scroller
+
+	go func() {
+		ticker := time.NewTicker(autoScrollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-scroller.stop:
+				return
+			case <-ticker.C:
+				_ = ui.screen.PostEvent(&EventAutoScroll{scroller: scroller})
+			}
+		}
+	}()
+}
+
+// Stops the auto-scrolling if it is running
+func (ui *UI) stopAutoScroll() {
+	if ui.scroller != nil {
+		close(ui.scroller.stop)
+		ui.scroller = nil
+	}
+}
+
+// Scrolls the selected pane by one line and extends the selection to the edge
+func (ui *UI) autoScroll(evt *EventAutoScroll) {
+	selected := ui.selectedPane()
+	if evt.scroller != ui.scroller || selected == nil {
+		return // stopped since the event was posted
+	}
+
+	row := 0
+	if ui.scroller.direction < 0 {
+		selected.scrollUp(1)
+	} else {
+		selected.scrollDown(1)
+		row = selected.content.height - 1
+	}
+	selected.vt.SelectEnd(ui.scroller.col, row)
+	ui.draw()
+}
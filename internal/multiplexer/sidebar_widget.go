@@ -1,13 +1,18 @@
 package multiplexer
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/views"
 )
 
 // Widget for displaying the process list
 type PaneListWidget struct {
-	box *views.BoxLayout
+	box  *views.BoxLayout
+	rows []*pane // pane shown on every row, nil for separators
 }
 
 // Creates a new sidebar widget
@@ -19,12 +24,14 @@ func NewPaneList(box *views.BoxLayout) *PaneListWidget {
 
 // Draws the process list in the sidebar
 func (s *PaneListWidget) render(panes []*pane, selected *pane, focused bool) {
+	s.rows = s.rows[:0]
 	for index, item := range panes {
 		// Add separator between alive and dead processes
 		if index > 0 && !panes[index-1].dead && item.dead {
 			spacer := views.NewTextBar()
 			spacer.SetLeft("──────────────────────", tcell.StyleDefault.Foreground(tcell.ColorGray))
 			s.box.AddWidget(spacer, 0)
+			s.rows = append(s.rows, nil)
 		}
 
 		style := tcell.StyleDefault
@@ -41,6 +48,56 @@ func (s *PaneListWidget) render(panes []*pane, selected *pane, focused bool) {
 		title := views.NewTextBar()
 		title.SetStyle(style)
 		title.SetLeft(" "+item.title, tcell.StyleDefault)
+		if item.dead && item.exit != nil {
+			if item.exit.success() {
+				title.SetRight("✓ ", tcell.StyleDefault.Foreground(tcell.ColorGreen))
+			} else {
+				title.SetRight("✗ "+item.exit.short()+" ", tcell.StyleDefault.Foreground(tcell.ColorRed))
+			}
+		}
+		if !item.dead {
+			switch item.readiness {
+			case readinessStarting:
+				title.SetRight("◌ ", tcell.StyleDefault.Foreground(tcell.ColorYellow))
+			case readinessReady:
+				title.SetRight("● ", tcell.StyleDefault.Foreground(tcell.ColorGreen))
+			case readinessFailed:
+				title.SetRight("! ", tcell.StyleDefault.Foreground(tcell.ColorRed))
+			}
+		}
 		s.box.AddWidget(title, 0)
+		s.rows = append(s.rows, item)
+
+		// Show which dependencies the pane is waiting for
+		if item.waiting {
+			status := views.NewTextBar()
+			status.SetStyle(tcell.StyleDefault.Foreground(tcell.ColorGray))
+			status.SetLeft("   ⧗ "+strings.Join(item.blockedBy, ", "), tcell.StyleDefault)
+			s.box.AddWidget(status, 0)
+			s.rows = append(s.rows, item)
+		}
+
+		// Show the retry count and the countdown to the next restart
+		if item.pendingRestart != nil {
+			retries := strconv.Itoa(item.restarts)
+			if item.restart.MaxRetries > 0 {
+				retries += "/" + strconv.Itoa(item.restart.MaxRetries)
+			}
+			countdown := item.restartIn().Round(time.Second)
+
+			status := views.NewTextBar()
+			status.SetStyle(tcell.StyleDefault.Foreground(tcell.ColorGray))
+			status.SetLeft("   ↻ "+retries+" in "+countdown.String(), tcell.StyleDefault)
+			s.box.AddWidget(status, 0)
+			s.rows = append(s.rows, item)
+		}
+	}
+}
+
+// Returns the pane shown on the row of the list, nil for separators
+func (s *PaneListWidget) paneAt(row int) *pane {
+	if row < 0 || row >= len(s.rows) {
+		return nil
 	}
+	return s.rows[row]
 }
@@ -1,7 +1,9 @@
 package multiplexer
 
 import (
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/views"
@@ -16,8 +18,19 @@ const SIDEBAR_WIDTH = 20
 type UI struct {
 	// State
 	panes        []*pane
-	focused      bool   // true when user is interacting with the active terminal
-	selected     string // key of currently selected process
+	focused      bool     // true when user is interacting with the active terminal
+	detachable   bool     // true when running as a session that clients detach from
+	selected     string   // key of currently selected process
+	layout       layout   // arrangement of the panes in the main area
+	splits       []string // keys of panes pinned to the split layouts
+	keybindings  Keybindings
+	prefixed     bool             // true after the prefix key was pressed
+	search       *searchPrompt    // open search prompt, nil when not searching
+	copying      *copyPrompt      // copy mode of a pane, nil when not copying
+	creating     *newPanePrompt   // open new pane prompt, nil otherwise
+	history      []string         // command lines entered in the new pane prompt
+	pasting      *strings.Builder // text of a paste in progress, nil otherwise
+	notice       string           // message shown above the hotkeys, e.g. a failed reload
 	screen       tcell.Screen
 	screenWidth  int
 	screenHeight int
@@ -25,31 +38,35 @@ type UI struct {
 	// Mouse interaction state for text selection
 	dragging bool              // true during text selection drag operation
 	click    *tcell.EventMouse // stores last click for double-click detection
+	clicks   int               // number of clicks in a row at the same position
+	scroller *autoScroll       // scrolls while dragging past the edge of the pane, nil otherwise
+
+	// Mouse forwarding to processes that track the mouse
+	capturing  bool             // true while a button pressed in the focused pane is held
+	mouseFlags tcell.MouseFlags // mouse events enabled on the screen
 
 	// UI elements
-	sidebarView    *views.ViewPort
-	activePaneView *views.ViewPort
-	menuBox        *views.BoxLayout
-	sidebarWidget  *PaneListWidget
-	hotkeysWidget  *HotkeysWidget
+	sidebarView   *views.ViewPort
+	menuBox       *views.BoxLayout
+	sidebarWidget *PaneListWidget
+	hotkeysWidget *HotkeysWidget
 }
 
 // NewUI creates a new UI instance
 func NewUI(screen tcell.Screen) *UI {
-	activePane := views.NewViewPort(screen, 0, 0, 0, 0)
 	sidebar := views.NewViewPort(screen, 0, 0, 0, 0)
 	menu := views.NewBoxLayout(views.Vertical)
 	menu.SetView(sidebar)
 
 	ui := &UI{
-		screen:         screen,
-		screenWidth:    0,
-		screenHeight:   0,
-		sidebarView:    sidebar,
-		activePaneView: activePane,
-		menuBox:        menu,
-		sidebarWidget:  NewPaneList(menu),
-		hotkeysWidget:  NewHotkeysWidget(menu),
+		screen:        screen,
+		screenWidth:   0,
+		screenHeight:  0,
+		sidebarView:   sidebar,
+		menuBox:       menu,
+		sidebarWidget: NewPaneList(menu),
+		hotkeysWidget: NewHotkeysWidget(menu),
+		keybindings:   DefaultKeybindings(),
 	}
 
 	return ui
@@ -71,16 +88,24 @@ func (ui *UI) resize(width int, height int) {
 	ui.screenHeight = height
 
 	ui.sidebarView.Resize(PADDING_WIDTH, PADDING_HEIGHT, SIDEBAR_WIDTH, height-PADDING_HEIGHT*2)
-	ui.activePaneView.Resize(PADDING_WIDTH+SIDEBAR_WIDTH+PADDING_WIDTH+1, PADDING_HEIGHT, width-PADDING_WIDTH-SIDEBAR_WIDTH-PADDING_WIDTH-PADDING_WIDTH-1, height-PADDING_HEIGHT*2)
+	ui.arrange()
+}
 
-	mw, mh := ui.activePaneView.Size()
-	for _, p := range ui.panes {
-		p.vt.Resize(mw, mh)
+// Returns the main area next to the sidebar where the panes are drawn
+func (ui *UI) mainArea() rect {
+	return rect{
+		x:      PADDING_WIDTH + SIDEBAR_WIDTH + PADDING_WIDTH + 1,
+		y:      PADDING_HEIGHT,
+		width:  max(ui.screenWidth-PADDING_WIDTH-SIDEBAR_WIDTH-PADDING_WIDTH-PADDING_WIDTH-1, 1),
+		height: max(ui.screenHeight-PADDING_HEIGHT*2, 1),
 	}
 }
 
 // Changes the selected process by the given offset
 func (ui *UI) move(offset int) {
+	if len(ui.panes) == 0 {
+		return
+	}
 	index := max(ui.selectedPaneIndex()+offset, 0)
 	if index >= len(ui.panes) {
 		index = len(ui.panes) - 1
@@ -144,8 +169,11 @@ func (ui *UI) draw() {
 	// Add spacer between sidebar and hotkeys
 	ui.menuBox.AddWidget(views.NewSpacer(), 1)
 
+	// Render the notice, wrapped to the width of the sidebar
+	ui.drawNotice()
+
 	// Render hotkeys
-	ui.hotkeysWidget.render(selected, ui.focused)
+	ui.hotkeysWidget.render(ui.activeBindings(), ui.actionLabel)
 
 	// Draw the menu (sidebar + hotkeys)
 	ui.menuBox.Draw()
@@ -156,17 +184,37 @@ func (ui *UI) draw() {
 		ui.screen.SetContent(SIDEBAR_WIDTH-1, i, '│', nil, borderStyle)
 	}
 
-	// Render virtual terminal
+	// Render virtual terminals of the visible panes
+	ui.arrange()
+	if ui.layout != layoutSingle {
+		area := ui.mainArea()
+		for x := area.x; x < area.x+area.width; x++ {
+			for y := area.y; y < area.y+area.height; y++ {
+				ui.screen.SetContent(x, y, ' ', nil, tcell.StyleDefault)
+			}
+		}
+	}
+	for _, p := range ui.visiblePanes() {
+		if ui.layout != layoutSingle {
+			ui.drawBorder(p, p == selected)
+		}
+		p.vt.Draw()
+	}
+
 	if selected != nil {
-		selected.vt.Draw()
 		if ui.focused {
 			y, x, _, _ := selected.vt.Cursor()
-			ui.screen.ShowCursor(SIDEBAR_WIDTH+1+x, y+PADDING_HEIGHT)
+			ui.screen.ShowCursor(selected.content.x+x, selected.content.y+y)
 		}
 		if !ui.focused {
 			ui.screen.HideCursor()
 		}
 	}
+
+	ui.drawSearchPrompt()
+	ui.drawCopyMode()
+	ui.drawNewPanePrompt()
+	ui.updateMouse()
 }
 
 func (ui *UI) addPane(p *pane) {
@@ -176,6 +224,33 @@ func (ui *UI) addPane(p *pane) {
 	if len(ui.panes) == 1 {
 		ui.selected = p.key
 	}
+	ui.arrange()
+}
+
+// Removes the pane from the screen and selects the pane that took its place
+func (ui *UI) removePane(p *pane) {
+	index := slices.Index(ui.panes, p)
+	if index < 0 {
+		return
+	}
+	ui.panes = slices.Delete(ui.panes, index, index+1)
+	ui.splits = slices.DeleteFunc(ui.splits, func(key string) bool { return key == p.key })
+
+	if ui.search != nil && ui.search.pane == p {
+		ui.search = nil
+	}
+	if ui.copying != nil && ui.copying.pane == p {
+		ui.copying = nil
+	}
+
+	if ui.selected == p.key {
+		ui.selected = ""
+		if len(ui.panes) > 0 {
+			ui.selected = ui.panes[min(index, len(ui.panes)-1)].key
+		}
+		ui.checkFocus()
+	}
+	ui.arrange()
 }
 
 // Sorts the panes and updates the selected index
@@ -198,17 +273,6 @@ func (ui *UI) sort() {
 	})
 }
 
-func (ui *UI) hasSelection() bool {
-	// if eh.ui.dragging && selected != nil {
-	// 	eh.multiplexer.copy()
-	// }
-	return false
-}
-
-func (ui *UI) resetDragging() {
-	// eh.ui.dragging = false
-}
-
 func (ui *UI) isSidebarClick(x int) bool {
 	return x < SIDEBAR_WIDTH && !ui.dragging
 }
@@ -217,49 +281,77 @@ func (ui *UI) isTerminalClick(x int) bool {
 	return x > SIDEBAR_WIDTH
 }
 
+// Selects the split under the mouse, leaving focus mode if its process cannot take input.
+// Returns false if the pane is already selected.
+func (ui *UI) selectPaneAt(x int, y int) bool {
+	p := ui.paneAt(x, y)
+	if p == nil || p.key == ui.selected {
+		return false
+	}
+
+	ui.selected = p.key
+	ui.checkFocus()
+	ui.draw()
+	return true
+}
+
+// Leaves focus mode if the selected pane cannot take input
+func (ui *UI) checkFocus() {
+	selected := ui.selectedPane()
+	if ui.focused && (selected == nil || !selected.killable || selected.dead) {
+		ui.blur()
+	}
+}
+
+// Selects the pane clicked in the sidebar and leaves focus mode
 func (ui *UI) selectPaneByCoordinates(x int, y int) {
-	// alive := 0
-	// for _, p := range eh.multiplexer.panes {
-	// 	if !p.dead {
-	// 		alive++
-	// 	}
-	// }
-	// // Adjust y coordinate if there's a separator between alive and dead processes
-	// if alive != len(eh.multiplexer.panes) {
-	// 	if y == alive {
-	// 		return
-	// 	}
-	// 	if y > alive {
-	// 		y--
-	// 	}
-	// }
-	// if y >= len(eh.multiplexer.panes) {
-	// 	return
-	// }
-	// eh.ui.selected = y
-	// eh.ui.Blur()
+	p := ui.sidebarWidget.paneAt(y - PADDING_HEIGHT)
+	if p == nil {
+		return
+	}
+
+	ui.selected = p.key
+	ui.blur()
+}
+
+// Adds the notice to the sidebar above the hotkeys
+func (ui *UI) drawNotice() {
+	if ui.notice == "" {
+		return
+	}
+
+	style := tcell.StyleDefault.Foreground(tcell.ColorRed)
+	for _, line := range wrapText(ui.notice, SIDEBAR_WIDTH-2) {
+		text := views.NewTextBar()
+		text.SetLeft(" "+line, style)
+		ui.menuBox.AddWidget(text, 0)
+	}
+	ui.menuBox.AddWidget(views.NewSpacer(), 0)
 }
 
-func (ui *UI) handleSelection(x int, y int) {
-	// Double-click detection for line selection
-	// if !eh.ui.dragging && eh.ui.click != nil && time.Since(eh.ui.click.When()) < time.Millisecond*500 {
-	// 	oldX, oldY := eh.ui.click.Position()
-	// 	if oldX == x && oldY == y {
-	// 		selected.vt.SelectStart(0, y)
-	// 		selected.vt.SelectEnd(eh.ui.screenWidth-1, y)
-	// 		eh.ui.dragging = true
-	// 		eh.ui.Draw()
-	// 		return
-	// 	}
-	// }
-	// eh.ui.click = evt
-	// offsetX := x - SIDEBAR_WIDTH - 1
-	// if eh.ui.dragging {
-	// 	selected.vt.SelectEnd(offsetX, y)
-	// }
-	// if !eh.ui.dragging {
-	// 	eh.ui.dragging = true
-	// 	selected.vt.SelectStart(offsetX, y)
-	// }
-	// eh.ui.draw()
+// Splits the text into lines of at most width runes, breaking at spaces when possible
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "; ") {
+		line := []rune{}
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = line[:0]
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, runes...)
+			for len(line) > width {
+				lines = append(lines, string(line[:width]))
+				line = append([]rune{}, line[width:]...)
+			}
+		}
+		if len(line) > 0 {
+			lines = append(lines, string(line))
+		}
+	}
+	return lines
 }
@@ -14,3 +14,11 @@ func Detach(cmd *exec.Cmd) {
 		Pgid:    0,
 	}
 }
+
+// Daemonize starts the command in a new session without a controlling
+// terminal, so it survives the terminal that started it
+func Daemonize(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+	}
+}
@@ -7,3 +7,6 @@ import "os/exec"
 
 func Detach(cmd *exec.Cmd) {
 }
+
+func Daemonize(cmd *exec.Cmd) {
+}
@@ -2,10 +2,14 @@ package process
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -13,35 +17,136 @@ import (
 
 var (
 	lock     sync.Mutex
-	cmds     = []*exec.Cmd{}
+	cmds     = map[*exec.Cmd]*tracked{}
 	killWait = 5 * time.Second
 )
 
+// A command stopped by Kill and Cleanup
+type tracked struct {
+	stop     StopOptions
+	exited   chan struct{} // closed by Wait, nil for commands reaped by exec.Cmd itself
+	stopping bool          // true once Kill started stopping the command
+}
+
+// StopOptions tells how Kill and Cleanup stop the process of a command
+type StopOptions struct {
+	Signal  syscall.Signal // signal asking the process to exit, SIGTERM when zero
+	Timeout time.Duration  // time the process gets to exit before it is killed, 5s when zero
+	Command []string       // command run before the signal is sent, e.g. `docker compose down`
+	Dir     string         // working directory of the stop command
+	Env     []string       // environment of the stop command
+}
+
+func (o StopOptions) signal() syscall.Signal {
+	if o.Signal != 0 {
+		return o.Signal
+	}
+	return syscall.SIGTERM
+}
+
+func (o StopOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return killWait
+}
+
+// Returns the longest time stopping the process can take
+func (o StopOptions) limit() time.Duration {
+	limit := o.timeout() + killWait
+	if len(o.Command) > 0 {
+		limit += o.timeout()
+	}
+	return limit
+}
+
+// SetStop sets how the process of the command is stopped
+func SetStop(cmd *exec.Cmd, opts StopOptions) {
+	lock.Lock()
+	defer lock.Unlock()
+	if t, ok := cmds[cmd]; ok {
+		t.stop = opts
+	}
+}
+
+// ParseSignal returns the signal with the name, e.g. `SIGINT`, `INT` or `int`
+func ParseSignal(name string) (syscall.Signal, error) {
+	if sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return sig, nil
+	}
+	names := slices.Sorted(maps.Keys(signals))
+	return 0, fmt.Errorf("unknown signal '%s', supported signals: SIG%s", name, strings.Join(names, ", SIG"))
+}
+
+// Shell returns the command line that runs the script with the shell of the
+// user, `$SHELL -c` or `/bin/sh -c` when SHELL is not set
+func Shell(script string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", script}
+	}
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return []string{shell, "-c", script}
+}
+
+// Command returns a command that Kill and Cleanup stop. It must be waited
+// for with Wait, which tells Kill when the process is gone.
 func Command(name string, args ...string) *exec.Cmd {
 	cmd := exec.Command(name, args...)
-	track(cmd)
+	track(cmd, make(chan struct{}))
 	return cmd
 }
 
+// CommandContext returns a command that gets SIGTERM when the context is
+// done, and is killed if it has not exited after the kill wait. Cleanup stops
+// it until the context is done.
 func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Cancel = func() error {
-		return Kill(cmd.Process)
+		return signal(cmd.Process, syscall.SIGTERM, leadsGroup(cmd.Process))
 	}
-	track(cmd)
+	cmd.WaitDelay = killWait
+	track(cmd, nil)
+	context.AfterFunc(ctx, func() {
+		untrack(cmd)
+	})
 	return cmd
 }
 
-func track(cmd *exec.Cmd) {
+// Wait waits for the command to exit like exec.Cmd.Wait, and stops tracking it
+func Wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	lock.Lock()
+	defer lock.Unlock()
+	if t, ok := cmds[cmd]; ok && t.exited != nil {
+		close(t.exited)
+	}
+	delete(cmds, cmd)
+	return err
+}
+
+func track(cmd *exec.Cmd, exited chan struct{}) {
 	lock.Lock()
 	defer lock.Unlock()
-	cmds = append(cmds, cmd)
+	cmds[cmd] = &tracked{exited: exited}
+}
+
+func untrack(cmd *exec.Cmd) {
+	lock.Lock()
+	defer lock.Unlock()
+	delete(cmds, cmd)
 }
 
 func Cleanup() error {
 	lock.Lock()
-	processes := make([]*exec.Cmd, len(cmds))
-	copy(processes, cmds)
+	processes := make([]*exec.Cmd, 0, len(cmds))
+	wait := killWait * 2
+	for cmd, t := range cmds {
+		processes = append(processes, cmd)
+		wait = max(wait, t.stop.limit())
+	}
 	lock.Unlock()
 
 	var wg sync.WaitGroup
@@ -56,12 +161,12 @@ func Cleanup() error {
 		}
 
 		wg.Add(1)
-		go func(p *os.Process) {
+		go func(cmd *exec.Cmd) {
 			defer wg.Done()
-			if err := Kill(p); err != nil {
+			if err := Kill(cmd); err != nil {
 				errors <- err
 			}
-		}(cmd.Process)
+		}(cmd)
 	}
 
 	// Wait for all processes to be killed
@@ -81,66 +186,117 @@ func Cleanup() error {
 			}
 		}
 		return nil
-	case <-time.After(killWait * 2):
+	case <-time.After(wait):
 		return syscall.ETIMEDOUT
 	}
 }
 
-func Kill(process *os.Process) error {
-	if process == nil {
+// Kill stops the process of the command the way set with SetStop: the stop
+// command runs first, then the stop signal is sent, and the process is killed
+// when it has not exited within the stop timeout. A process leading its own
+// process group, like the ones started with Detach or in a new session, is
+// stopped with its whole group so its children are not left behind. Kill
+// returns once Wait reaped the process, commands that are already being
+// stopped are only waited for. Commands reaped by exec.Cmd itself only get
+// the stop signal.
+func Kill(cmd *exec.Cmd) error {
+	if cmd == nil || cmd.Process == nil {
 		return nil
 	}
-	// slog.Info("killing process", "pid", process.Pid)
+	// slog.Info("killing process", "pid", cmd.Process.Pid)
 
-	switch runtime.GOOS {
-	case "windows":
-		if err := process.Kill(); err != nil {
-			slog.Error("failed to kill", "pid", process.Pid)
-			return err
+	lock.Lock()
+	t, ok := cmds[cmd]
+	stopping := ok && t.stopping
+	if ok {
+		t.stopping = true
+	}
+	lock.Unlock()
+
+	switch {
+	case !ok:
+		// Not tracked or already reaped
+		return nil
+	case t.exited == nil:
+		return signal(cmd.Process, t.stop.signal(), leadsGroup(cmd.Process))
+	case stopping:
+		select {
+		case <-t.exited:
+			return nil
+		case <-time.After(t.stop.limit()):
+			return syscall.ETIMEDOUT
 		}
-	default:
-		if err := process.Signal(syscall.SIGTERM); err != nil {
-			slog.Error("failed to send sigterm", "pid", process.Pid)
+	}
+
+	opts := t.stop
+	group := leadsGroup(cmd.Process)
+
+	if len(opts.Command) > 0 {
+		runStopCommand(opts)
+	}
+
+	// The process ID may belong to another process once the process was
+	// reaped, it is only signaled before
+	send := func(sig syscall.Signal) error {
+		if exited(t) {
+			return nil
+		}
+		if err := signal(cmd.Process, sig, group); err != nil && !exited(t) {
 			return err
 		}
+		return nil
 	}
 
-	done := make(chan struct{})
-	go func() {
-		process.Wait()
-		close(done)
-	}()
+	if err := send(opts.signal()); err != nil {
+		slog.Error("failed to send signal", "pid", cmd.Process.Pid, "signal", opts.signal())
+		return err
+	}
 
 	select {
-	case <-done:
-		// slog.Info("process killed with term", "pid", process.Pid)
+	case <-t.exited:
+		// slog.Info("process stopped with signal", "pid", cmd.Process.Pid)
 		break
-	case <-time.After(killWait):
-		// slog.Info("process not responding, sending sigkill", "pid", process.Pid)
-		if err := process.Signal(syscall.SIGKILL); err != nil {
-			slog.Error("failed to send sigkill", "pid", process.Pid)
+	case <-time.After(opts.timeout()):
+		// slog.Info("process not responding, sending sigkill", "pid", cmd.Process.Pid)
+		if err := send(syscall.SIGKILL); err != nil {
+			slog.Error("failed to send sigkill", "pid", cmd.Process.Pid)
 			return err
 		}
 
 		// Wait for SIGKILL to complete
 		select {
-		case <-done:
-			// slog.Info("process killed with kill", "pid", process.Pid)
+		case <-t.exited:
+			// slog.Info("process killed with kill", "pid", cmd.Process.Pid)
 			break
 		case <-time.After(killWait):
-			// slog.Info("timed out waiting for sigkill", "pid", process.Pid)
+			// slog.Info("timed out waiting for sigkill", "pid", cmd.Process.Pid)
 			return syscall.ETIMEDOUT
 		}
 	}
-	lock.Lock()
-	defer lock.Unlock()
-	for i := len(cmds) - 1; i >= 0; i-- {
-		if cmds[i].Process != nil && cmds[i].Process.Pid == process.Pid {
-			cmds[i] = cmds[len(cmds)-1]
-			cmds = cmds[:len(cmds)-1]
-			break
-		}
-	}
-	// slog.Info("untracked process", "pid", process.Pid)
 	return nil
 }
+
+// Reports whether Wait reaped the process of the command
+func exited(t *tracked) bool {
+	select {
+	case <-t.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// Runs the stop command, for at most the stop timeout
+func runStopCommand(opts StopOptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, opts.Command[0], opts.Command[1:]...)
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Env
+	// Children of the stop command may keep its output open
+	cmd.WaitDelay = time.Second
+	if output, err := cmd.CombinedOutput(); err != nil {
+		slog.Error("stop command failed", "command", opts.Command, "err", err, "output", string(output))
+	}
+}
@@ -0,0 +1,148 @@
+//go:build !windows
+// +build !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{name: "SIGINT", want: syscall.SIGINT},
+		{name: "TERM", want: syscall.SIGTERM},
+		{name: "kill", want: syscall.SIGKILL},
+		{name: "SIGSTOP", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSignal(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSignal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSignal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Starts a shell in its own process group with a child that traps the signal
+// and writes its pid, then waits for the child to start
+func startGroup(t *testing.T, script string) (*exec.Cmd, int) {
+	t.Helper()
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	cmd := Command("/bin/sh", "-c", script+" & echo $! > "+pidFile+"; wait")
+	Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	go Wait(cmd)
+
+	for range 100 {
+		data, _ := os.ReadFile(pidFile)
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return cmd, pid
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("the child did not start")
+	return nil, 0
+}
+
+// Reports whether the process exits within a second, the child is reaped by
+// whoever adopted it so a zombie counts as exited
+func exitSoon(pid int) bool {
+	for range 50 {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if syscall.Kill(pid, 0) != nil || err == nil && strings.Contains(string(stat), ") Z ") {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func TestKill_ProcessGroup(t *testing.T) {
+	cmd, child := startGroup(t, "sleep 60")
+	SetStop(cmd, StopOptions{Signal: syscall.SIGHUP})
+
+	started := time.Now()
+	if err := Kill(cmd); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > killWait/2 {
+		t.Errorf("Kill() took %v, want the child to exit on the signal", elapsed)
+	}
+	if !exitSoon(child) {
+		syscall.Kill(child, syscall.SIGKILL)
+		t.Error("Kill() left the child of the process running")
+	}
+}
+
+func TestKill_StopCommandAndTimeout(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "stopped")
+	cmd, child := startGroup(t, "trap '' INT TERM; sleep 60")
+	SetStop(cmd, StopOptions{
+		Timeout: 200 * time.Millisecond,
+		Command: []string{"/bin/sh", "-c", "touch stopped"},
+		Dir:     filepath.Dir(marker),
+	})
+
+	started := time.Now()
+	if err := Kill(cmd); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("Kill() did not run the stop command: %v", err)
+	}
+	if !exitSoon(child) {
+		syscall.Kill(child, syscall.SIGKILL)
+		t.Error("Kill() left the child that ignores the signal running")
+	}
+	if elapsed := time.Since(started); elapsed > killWait {
+		t.Errorf("Kill() took %v, want about the stop timeout", elapsed)
+	}
+}
+
+func TestWait_Untracks(t *testing.T) {
+	cmd := Command("/bin/sh", "-c", "exit 0")
+	SetStop(cmd, StopOptions{Timeout: time.Minute})
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := Wait(cmd); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	lock.Lock()
+	_, tracked := cmds[cmd]
+	lock.Unlock()
+	if tracked {
+		t.Error("Wait() left the command tracked")
+	}
+
+	// The process ID may already belong to another process
+	started := time.Now()
+	if err := Kill(cmd); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Kill() took %v on a reaped command", elapsed)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals accepted by ParseSignal, by name without the SIG prefix
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// Reports whether the process leads its own process group
+func leadsGroup(process *os.Process) bool {
+	pgid, err := syscall.Getpgid(process.Pid)
+	return err == nil && pgid == process.Pid
+}
+
+// Sends the signal to the process group the process leads, or to the process alone
+func signal(process *os.Process, sig syscall.Signal, group bool) error {
+	if group {
+		return syscall.Kill(-process.Pid, sig)
+	}
+	return process.Signal(sig)
+}
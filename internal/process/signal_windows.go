@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals accepted by ParseSignal, by name without the SIG prefix
+var signals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+func leadsGroup(process *os.Process) bool {
+	return false
+}
+
+// Windows cannot deliver signals, the process is killed
+func signal(process *os.Process, sig syscall.Signal, group bool) error {
+	return process.Kill()
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+
+	"golang.org/x/term"
+)
+
+// Attach connects the current terminal to the session listening on the
+// socket path. It returns once the client is detached or the session exits,
+// with the reason reported by the session.
+func Attach(path string) (string, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to session socket '%s': %w", path, err)
+	}
+	defer conn.Close()
+
+	stdin := int(os.Stdin.Fd())
+	stdout := int(os.Stdout.Fd())
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to put the terminal in raw mode: %w", err)
+	}
+	defer term.Restore(stdin, state)
+
+	sendSize := func() error {
+		cols, rows, err := term.GetSize(stdout)
+		if err != nil {
+			return err
+		}
+		return writeFrame(conn, frameResize, encodeSize(cols, rows))
+	}
+	if err := sendSize(); err != nil {
+		return "", fmt.Errorf("failed to send the window size: %w", err)
+	}
+
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer signal.Stop(resized)
+	go func() {
+		for range resized {
+			if sendSize() != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if writeFrame(conn, frameInput, buf[:n]) != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		kind, payload, err := readFrame(conn)
+		if err != nil {
+			return reasonExited, nil
+		}
+
+		switch kind {
+		case frameOutput:
+			os.Stdout.Write(payload)
+		case frameExit:
+			return string(payload), nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package session keeps the multiplexer running in a background daemon that
+// terminal clients attach to and detach from over a Unix-domain socket.
+//
+// The daemon renders into a Tty that forwards the output to the attached
+// client, the client forwards its keyboard input and window size back.
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nodge/multiplexer/internal/socket"
+)
+
+// Frame types exchanged between the daemon and its client
+const (
+	frameInput  byte = 'i' // client -> daemon, raw terminal input
+	frameResize byte = 'r' // client -> daemon, window size as two uint16 (columns, rows)
+	frameOutput byte = 'o' // daemon -> client, raw terminal output
+	frameExit   byte = 'x' // daemon -> client, the client is detached, payload is the reason
+)
+
+// Maximum payload size of a single frame
+const maxFrameSize = 1 << 20
+
+// Reasons sent with frameExit
+const (
+	reasonDetached = "detached"
+	reasonExited   = "exited"
+)
+
+// SocketPath returns the socket path of the named session
+func SocketPath(name string) string {
+	return filepath.Join(socket.Dir(), "multiplexer-"+strconv.Itoa(os.Getuid())+"-session-"+name+".sock")
+}
+
+// Writes a single frame
+func writeFrame(w io.Writer, kind byte, payload []byte) error {
+	header := make([]byte, 5)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reads a single frame
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds the limit", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+// Encodes the window size payload of frameResize
+func encodeSize(cols int, rows int) []byte {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload[0:], uint16(cols))
+	binary.BigEndian.PutUint16(payload[2:], uint16(rows))
+	return payload
+}
+
+// Decodes the window size payload of frameResize
+func decodeSize(payload []byte) (int, int, error) {
+	if len(payload) != 4 {
+		return 0, 0, fmt.Errorf("invalid window size payload")
+	}
+	return int(binary.BigEndian.Uint16(payload[0:])), int(binary.BigEndian.Uint16(payload[2:])), nil
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestFrame_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, frameOutput, []byte("hello")); err != nil {
+		t.Fatalf("writeFrame() error = %v", err)
+	}
+	if err := writeFrame(&buf, frameResize, encodeSize(120, 40)); err != nil {
+		t.Fatalf("writeFrame() error = %v", err)
+	}
+
+	kind, payload, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame() error = %v", err)
+	}
+	if kind != frameOutput || string(payload) != "hello" {
+		t.Errorf("readFrame() = %q %q, want %q %q", kind, payload, frameOutput, "hello")
+	}
+
+	kind, payload, err = readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame() error = %v", err)
+	}
+	cols, rows, err := decodeSize(payload)
+	if kind != frameResize || err != nil || cols != 120 || rows != 40 {
+		t.Errorf("readFrame() = %q %dx%d (%v), want %q 120x40", kind, cols, rows, err, frameResize)
+	}
+}
+
+func TestReadFrame_TooLarge(t *testing.T) {
+	header := make([]byte, 5)
+	header[0] = frameOutput
+	binary.BigEndian.PutUint32(header[1:], maxFrameSize+1)
+
+	if _, _, err := readFrame(bytes.NewReader(header)); err == nil {
+		t.Error("readFrame() expected error for oversized frame")
+	}
+}
+
+func TestDecodeSize_Invalid(t *testing.T) {
+	if _, _, err := decodeSize([]byte{1, 2}); err == nil {
+		t.Error("decodeSize() expected error for short payload")
+	}
+}
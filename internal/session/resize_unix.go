@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package session
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func notifyResize(ch chan os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
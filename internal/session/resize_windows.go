@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package session
+
+import "os"
+
+func notifyResize(ch chan os.Signal) {
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/nodge/multiplexer/internal/socket"
+)
+
+// Server accepts clients on a Unix-domain socket and attaches them to a Tty
+type Server struct {
+	tty      *Tty
+	listener net.Listener
+	reengage func(fn func())
+}
+
+// Listen starts accepting clients on the socket path. Switching clients
+// requires the screen to be suspended, reengage must suspend the screen,
+// run fn, then resume and redraw the screen.
+func Listen(path string, tty *Tty, reengage func(fn func())) (*Server, error) {
+	listener, err := socket.Listen(path)
+	if errors.Is(err, socket.ErrInUse) {
+		return nil, fmt.Errorf("session socket '%s' is already in use", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on session socket '%s': %w", path, err)
+	}
+
+	s := &Server{
+		tty:      tty,
+		listener: listener,
+		reengage: reengage,
+	}
+	go s.accept()
+
+	return s, nil
+}
+
+// Close stops accepting clients and removes the socket
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// Alive reports whether a session daemon is listening on the socket path
+func Alive(path string) bool {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Error("session socket accept failed", "err", err)
+			}
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// Attaches the client once it reported its window size and forwards its input
+func (s *Server) serve(conn net.Conn) {
+	kind, payload, err := readFrame(conn)
+	if err != nil || kind != frameResize {
+		conn.Close()
+		return
+	}
+	cols, rows, err := decodeSize(payload)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	s.reengage(func() {
+		s.tty.attach(conn, cols, rows)
+	})
+
+	for {
+		kind, payload, err := readFrame(conn)
+		if err != nil {
+			// Clients replaced by another one are already let go
+			if s.tty.owns(conn) {
+				s.reengage(func() {
+					s.tty.drop(conn)
+				})
+			}
+			return
+		}
+
+		switch kind {
+		case frameInput:
+			s.tty.forward(conn, payload)
+		case frameResize:
+			if cols, rows, err := decodeSize(payload); err == nil {
+				s.tty.resize(conn, cols, rows)
+			}
+		}
+	}
+}
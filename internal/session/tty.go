@@ -0,0 +1,234 @@
+package session
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// How long a client may take to accept a frame before it is let go, so a
+// client that stopped reading does not hold up the screen
+var writeTimeout = 5 * time.Second
+
+// Tty is a tcell.Tty backed by the client currently attached to the session.
+// Without a client, output is discarded and no input arrives.
+//
+// Clients are switched while the screen is suspended: the client set with
+// attach takes over on the next Start, and the client asked to detach is let
+// go on the next Stop. The screen writes its teardown sequences to the old
+// client and its setup sequences to the new one in between.
+type Tty struct {
+	mu       sync.Mutex
+	client   net.Conn // attached client
+	next     net.Conn // client taking over on the next Start
+	detach   bool     // let the client go on the next Stop
+	cols     int
+	rows     int
+	notify   func()
+	input    chan []byte
+	drain    chan struct{}
+	leftover []byte
+}
+
+// NewTty creates a tty without an attached client
+func NewTty() *Tty {
+	return &Tty{
+		cols:  80,
+		rows:  24,
+		input: make(chan []byte, 64),
+		drain: make(chan struct{}),
+	}
+}
+
+// Start attaches the pending client, detaching the previous one
+func (t *Tty) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.next != nil {
+		t.release(reasonDetached)
+		t.client = t.next
+		t.next = nil
+	}
+	t.drain = make(chan struct{})
+
+	return nil
+}
+
+// Stop lets the client go if it was asked to detach
+func (t *Tty) Stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.detach {
+		t.release(reasonDetached)
+		t.detach = false
+	}
+
+	return nil
+}
+
+// Drain wakes up a blocked Read
+func (t *Tty) Drain() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.drain:
+	default:
+		close(t.drain)
+	}
+
+	return nil
+}
+
+// NotifyResize registers the callback invoked when the client window size changes
+func (t *Tty) NotifyResize(cb func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notify = cb
+}
+
+// WindowSize returns the window size of the last attached client
+func (t *Tty) WindowSize() (tcell.WindowSize, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return tcell.WindowSize{Width: t.cols, Height: t.rows}, nil
+}
+
+// Read returns input of the attached client
+func (t *Tty) Read(p []byte) (int, error) {
+	if len(t.leftover) == 0 {
+		t.mu.Lock()
+		drain := t.drain
+		t.mu.Unlock()
+
+		select {
+		case chunk := <-t.input:
+			t.leftover = chunk
+		case <-drain:
+			return 0, nil
+		}
+	}
+
+	n := copy(p, t.leftover)
+	t.leftover = t.leftover[n:]
+	return n, nil
+}
+
+// Write sends output to the attached client, or discards it
+func (t *Tty) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		if err := send(t.client, frameOutput, p); err != nil {
+			// The client is gone or stuck, the server detaches it once it notices
+			t.client.Close()
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close lets the attached client go, the session has ended
+func (t *Tty) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.release(reasonExited)
+	if t.next != nil {
+		send(t.next, frameExit, []byte(reasonExited))
+		t.next.Close()
+		t.next = nil
+	}
+
+	return nil
+}
+
+// Detach asks the attached client to be let go on the next Stop
+func (t *Tty) Detach() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.detach = true
+}
+
+// Queues the client to take over on the next Start
+func (t *Tty) attach(conn net.Conn, cols int, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.next != nil {
+		send(t.next, frameExit, []byte(reasonDetached))
+		t.next.Close()
+	}
+	t.next = conn
+	if cols > 0 && rows > 0 {
+		t.cols, t.rows = cols, rows
+	}
+}
+
+// Returns true if the client is attached or about to be
+func (t *Tty) owns(conn net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.client == conn || t.next == conn
+}
+
+// Detaches the client on the next Stop if it is still the attached one
+func (t *Tty) drop(conn net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == conn {
+		t.detach = true
+	}
+	if t.next == conn {
+		t.next = nil
+	}
+}
+
+// Updates the window size if the client is the attached one
+func (t *Tty) resize(conn net.Conn, cols int, rows int) {
+	t.mu.Lock()
+	if t.client != conn || cols <= 0 || rows <= 0 || (t.cols == cols && t.rows == rows) {
+		t.mu.Unlock()
+		return
+	}
+	t.cols, t.rows = cols, rows
+	notify := t.notify
+	t.mu.Unlock()
+
+	if notify != nil {
+		notify()
+	}
+}
+
+// Forwards input if the client is the attached one
+func (t *Tty) forward(conn net.Conn, data []byte) {
+	t.mu.Lock()
+	attached := t.client == conn
+	t.mu.Unlock()
+
+	if attached {
+		t.input <- data
+	}
+}
+
+// Sends the exit frame to the attached client and closes it, must be called with the lock held
+func (t *Tty) release(reason string) {
+	if t.client == nil {
+		return
+	}
+	send(t.client, frameExit, []byte(reason))
+	t.client.Close()
+	t.client = nil
+}
+
+// Writes a frame to a client, giving up after writeTimeout
+func send(conn net.Conn, kind byte, payload []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return writeFrame(conn, kind, payload)
+}
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTty_StuckClient(t *testing.T) {
+	defer func(timeout time.Duration) { writeTimeout = timeout }(writeTimeout)
+	writeTimeout = 100 * time.Millisecond
+
+	// The client never reads its end of the pipe
+	server, client := net.Pipe()
+	defer client.Close()
+
+	tty := NewTty()
+	tty.attach(server, 80, 24)
+	tty.Start()
+
+	done := make(chan struct{})
+	go func() {
+		tty.Write([]byte("output"))
+		tty.Write([]byte("more output"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Write() blocked on a client that does not read")
+	}
+
+	// The client was let go
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Error("Write() left the stuck client connected")
+	}
+}
@@ -0,0 +1,100 @@
+// Package shellwords splits command lines into arguments with the quoting
+// rules of a POSIX shell.
+//
+// Words are separated by unquoted blanks and newlines. Single quotes keep
+// everything up to the next single quote literally, double quotes keep
+// everything but the backslash escapes of `$`, "`", `"`, `\` and newline, and
+// an unquoted backslash keeps the next character. An unquoted `#` at the
+// start of a word starts a comment. Nothing is expanded: variables, globs,
+// pipes and redirections are left to a shell.
+package shellwords
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors returned for incomplete command lines
+var (
+	ErrUnterminatedSingleQuote = errors.New("unterminated single quote")
+	ErrUnterminatedDoubleQuote = errors.New("unterminated double quote")
+	ErrTrailingBackslash       = errors.New("trailing backslash")
+)
+
+// Split splits the command line into words
+func Split(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false // true once the current word has a character or quotes
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case r == '#' && !inWord:
+			return words, nil
+
+		case r == '\\':
+			i++
+			if i == len(runes) {
+				return nil, ErrTrailingBackslash
+			}
+			// A backslash followed by a newline continues the line
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, ErrUnterminatedSingleQuote
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, ErrUnterminatedDoubleQuote
+			}
+			inWord = true
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Returns the index of the first r in runes at or after start, -1 if there is none
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,38 @@
+package shellwords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		words []string
+		err   error
+	}{
+		{name: "empty", line: "  ", words: []string{}},
+		{name: "blanks", line: " go  test\t./... ", words: []string{"go", "test", "./..."}},
+		{name: "single quotes", line: `echo 'hello world' '$HOME \n'`, words: []string{"echo", "hello world", `$HOME \n`}},
+		{name: "double quotes", line: `echo "say \"hi\"" "a\b" "\$HOME"`, words: []string{"echo", `say "hi"`, `a\b`, "$HOME"}},
+		{name: "adjacent quotes", line: `a'b'"c"d`, words: []string{"abcd"}},
+		{name: "empty quotes", line: `echo '' ""`, words: []string{"echo", "", ""}},
+		{name: "backslash", line: `echo a\ b \'`, words: []string{"echo", "a b", "'"}},
+		{name: "line continuation", line: "echo a \\\n  b", words: []string{"echo", "a", "b"}},
+		{name: "operators are words", line: "make build && ./app | tee log", words: []string{"make", "build", "&&", "./app", "|", "tee", "log"}},
+		{name: "comment", line: "echo a#b # comment", words: []string{"echo", "a#b"}},
+		{name: "unterminated single quote", line: "echo 'a", err: ErrUnterminatedSingleQuote},
+		{name: "unterminated double quote", line: `echo "a\"`, err: ErrUnterminatedDoubleQuote},
+		{name: "trailing backslash", line: `echo a\`, err: ErrTrailingBackslash},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, err := Split(test.line)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.words, words)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package socket
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// Returns true if the file belongs to the user
+func owned(info fs.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || int(stat.Uid) == os.Getuid()
+}
@@ -0,0 +1,71 @@
+// Package socket creates the Unix-domain sockets of the multiplexer so that
+// only the user running it can connect to them.
+package socket
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrInUse is returned by Listen when a server is listening on the path
+var ErrInUse = errors.New("socket is already in use")
+
+// Dir returns the directory of the sockets: $XDG_RUNTIME_DIR, else a
+// directory of the user in the temporary directory
+func Dir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "multiplexer-"+strconv.Itoa(os.Getuid()))
+}
+
+// Listen listens on the socket path, which only the user can connect to. A
+// missing parent directory is created private to the user. A socket left
+// behind by a crashed server is replaced, but paths that belong to another
+// user or are not sockets are never removed.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Other users may replace the socket in a shared directory unless it is sticky, like /tmp
+	if !owned(info) && info.Mode().Perm()&0o022 != 0 && info.Mode()&fs.ModeSticky == 0 {
+		return nil, fmt.Errorf("directory '%s' is writable by other users", dir)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		switch {
+		case info.Mode()&fs.ModeSocket == 0:
+			return nil, fmt.Errorf("'%s' exists and is not a socket", path)
+		case !owned(info):
+			return nil, fmt.Errorf("'%s' belongs to another user", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, ErrInUse
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// The permissions of a new socket depend on the umask
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+// +build !windows
+
+package socket
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run")
+	path := filepath.Join(dir, "test.sock")
+
+	listener, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	// The missing directory is created private to the user
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("os.Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("directory permissions = %o, want 700", perm)
+	}
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket permissions = %o, want 600", perm)
+	}
+
+	if _, err := Listen(path); !errors.Is(err, ErrInUse) {
+		t.Errorf("Listen() on a live socket error = %v, want ErrInUse", err)
+	}
+
+	// A socket left behind is replaced
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	listener, err = Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() on a stale socket error = %v", err)
+	}
+	listener.Close()
+}
+
+func TestListen_NotASocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Listen(path); err == nil {
+		t.Fatal("Listen() error = nil, want an error")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Errorf("the file was changed: %q, %v", data, err)
+	}
+}
@@ -20,10 +20,12 @@ func (c *cell) rune() rune {
 // Erasing removes characters from the screen without affecting other characters
 // on the screen. Erased characters are lost. The cursor position does not
 // change when erasing characters or lines. Erasing resets the attributes, but
-// applies the background color of the passed style
+// applies the background color of the passed style. An erased row no longer
+// continues on the next one
 func (c *cell) erase(s tcell.Style) {
 	_, bg, _ := s.Decompose()
 	c.content = 0
+	c.wrapped = false
 	c.attrs = tcell.StyleDefault.Background(bg)
 }
 
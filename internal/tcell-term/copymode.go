@@ -0,0 +1,327 @@
+package tcellterm
+
+import (
+	"unicode"
+)
+
+// CopyMotion moves the cursor of the copy mode
+type CopyMotion int
+
+const (
+	CopyLeft         CopyMotion = iota // previous column
+	CopyRight                          // next column
+	CopyUp                             // previous line
+	CopyDown                           // next line
+	CopyWordForward                    // start of the next word
+	CopyWordBackward                   // start of the current or previous word
+	CopyLineStart                      // first column
+	CopyLineEnd                        // last character of the line
+	CopyTop                            // first line of the scrollback
+	CopyBottom                         // last line with content
+	CopyHalfPageUp                     // half a screen up
+	CopyHalfPageDown                   // half a screen down
+)
+
+// Visual is the kind of selection made in copy mode
+type Visual int
+
+const (
+	VisualNone  Visual = iota
+	VisualChar         // from the anchor to the cursor
+	VisualLine         // whole lines from the anchor to the cursor
+	VisualBlock        // the rectangle between the anchor and the cursor
+)
+
+// copyMode holds the cursor moved over the scrollback and the active screen
+// by the keyboard. Lines are numbered like the search.
+type copyMode struct {
+	line   int
+	col    int
+	visual Visual
+
+	// Position of the cursor when the visual selection started
+	anchorLine int
+	anchorCol  int
+}
+
+// EnterCopyMode shows a cursor at the position of the terminal cursor in the
+// view, which the copy mode motions move
+func (vt *VT) EnterCopyMode() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	line, ok := vt.viewLine(int(vt.cursor.row))
+	if !ok {
+		line = vt.primaryScrollback.len() + len(vt.activeScreen) - 1
+	}
+	vt.copy = &copyMode{line: line, col: int(vt.cursor.col)}
+	vt.clampCopy()
+}
+
+// ExitCopyMode removes the cursor and the selection, and scrolls back to the
+// bottom
+func (vt *VT) ExitCopyMode() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.copy == nil {
+		return
+	}
+	vt.copy = nil
+	vt.clearSelection()
+	vt.resetScroll()
+}
+
+// IsCopying returns true in copy mode
+func (vt *VT) IsCopying() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.copy != nil
+}
+
+// CopyCursor returns the position of the copy mode cursor in the view, false
+// if it is not visible
+func (vt *VT) CopyCursor() (int, int, bool) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.copy == nil {
+		return 0, 0, false
+	}
+	row := vt.copy.line - vt.viewTop()
+	if row < 0 || row >= vt.height() {
+		return 0, 0, false
+	}
+	return row, vt.copy.col, true
+}
+
+// CopyVisual returns the kind of the copy mode selection
+func (vt *VT) CopyVisual() Visual {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.copy == nil {
+		return VisualNone
+	}
+	return vt.copy.visual
+}
+
+// SetCopyVisual starts a selection of the kind at the cursor, or switches the
+// current one to the kind. Setting the current kind again ends the selection.
+func (vt *VT) SetCopyVisual(visual Visual) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.copy == nil {
+		return
+	}
+
+	switch {
+	case visual == vt.copy.visual:
+		vt.copy.visual = VisualNone
+	case vt.copy.visual == VisualNone:
+		vt.copy.visual = visual
+		vt.copy.anchorLine, vt.copy.anchorCol = vt.copy.line, vt.copy.col
+	default:
+		vt.copy.visual = visual
+	}
+	vt.updateCopySelection()
+}
+
+// CopyMove moves the copy mode cursor count times and scrolls the view to it
+func (vt *VT) CopyMove(motion CopyMotion, count int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	c := vt.copy
+	if c == nil {
+		return
+	}
+
+	count = max(count, 1)
+	last := vt.lastCopyLine()
+	switch motion {
+	case CopyLeft:
+		c.col -= count
+	case CopyRight:
+		c.col += count
+	case CopyUp:
+		c.line -= count
+	case CopyDown:
+		c.line = min(c.line+count, last)
+	case CopyHalfPageUp:
+		c.line -= count * max(vt.height()/2, 1)
+	case CopyHalfPageDown:
+		c.line = min(c.line+count*max(vt.height()/2, 1), last)
+	case CopyWordForward:
+		for range count {
+			c.line, c.col = vt.wordForward(c.line, c.col)
+		}
+	case CopyWordBackward:
+		for range count {
+			c.line, c.col = vt.wordBackward(c.line, c.col)
+		}
+	case CopyLineStart:
+		c.col = 0
+	case CopyLineEnd:
+		c.col = len(trimBlank(vt.searchLine(c.line), 0)) - 1
+	case CopyTop:
+		c.line, c.col = 0, 0
+	case CopyBottom:
+		c.line, c.col = last, 0
+	}
+	vt.clampCopy()
+	vt.updateCopySelection()
+	vt.revealLine(c.line)
+}
+
+// Keeps the copy mode cursor and the anchor of its selection on existing
+// cells, the lines may change on resizes and screen switches
+func (vt *VT) clampCopy() {
+	c := vt.copy
+	if c == nil {
+		return
+	}
+	total := vt.primaryScrollback.len() + len(vt.activeScreen)
+	c.line = max(min(c.line, total-1), 0)
+	c.col = max(min(c.col, len(vt.searchLine(c.line))-1), 0)
+	c.anchorLine = max(min(c.anchorLine, total-1), 0)
+	c.anchorCol = max(min(c.anchorCol, len(vt.searchLine(c.anchorLine))-1), 0)
+}
+
+// Returns the last line the copy mode cursor moves to by default, the line
+// of the terminal cursor or a line with content below it
+func (vt *VT) lastCopyLine() int {
+	cursor := vt.primaryScrollback.len() + int(vt.cursor.row)
+	last := vt.primaryScrollback.len() + len(vt.activeScreen) - 1
+	for last > cursor && blankRow(vt.searchLine(last)) {
+		last -= 1
+	}
+	return last
+}
+
+// Sets the selection to the visual selection of the copy mode
+func (vt *VT) updateCopySelection() {
+	c := vt.copy
+	switch c.visual {
+	case VisualNone:
+		vt.clearSelection()
+	case VisualChar:
+		vt.selection = &selection{startX: c.anchorCol, startY: c.anchorLine, endX: c.col, endY: c.line}
+	case VisualLine:
+		start, end := min(c.anchorLine, c.line), max(c.anchorLine, c.line)
+		vt.selection = &selection{startX: 0, startY: start, endX: max(len(vt.searchLine(end))-1, 0), endY: end}
+	case VisualBlock:
+		vt.selection = &selection{startX: c.anchorCol, startY: c.anchorLine, endX: c.col, endY: c.line, block: true}
+	}
+}
+
+// Scrolls the view as little as possible to show the line
+func (vt *VT) revealLine(line int) {
+	top := vt.viewTop()
+	switch {
+	case line < top:
+		vt.scroll = line
+	case line >= top+vt.height():
+		vt.scroll = line - vt.height() + 1
+	default:
+		return
+	}
+	if vt.scroll >= vt.primaryScrollback.len() {
+		vt.resetScroll()
+	}
+}
+
+// Returns the line shown at the top of the view
+func (vt *VT) viewTop() int {
+	if vt.scroll == -1 {
+		return vt.primaryScrollback.len()
+	}
+	return vt.scroll
+}
+
+// Returns the start of the next word, a line break ends a word unless the
+// row is wrapped
+func (vt *VT) wordForward(line int, col int) (int, int) {
+	class := vt.charClass(line, col)
+	for {
+		next, nextCol, lineBreak, ok := vt.step(line, col, 1)
+		if !ok {
+			return line, col
+		}
+		line, col = next, nextCol
+
+		current := vt.charClass(line, col)
+		if lineBreak || current == 0 {
+			class = 0
+		}
+		if current != 0 && current != class {
+			return line, col
+		}
+	}
+}
+
+// Returns the start of the word before the position, or of the word it is in
+func (vt *VT) wordBackward(line int, col int) (int, int) {
+	// Skip the blanks before the position
+	for {
+		prev, prevCol, _, ok := vt.step(line, col, -1)
+		if !ok {
+			return line, col
+		}
+		line, col = prev, prevCol
+		if vt.charClass(line, col) != 0 {
+			break
+		}
+	}
+
+	class := vt.charClass(line, col)
+	for {
+		prev, prevCol, lineBreak, ok := vt.step(line, col, -1)
+		if !ok || lineBreak || vt.charClass(prev, prevCol) != class {
+			return line, col
+		}
+		line, col = prev, prevCol
+	}
+}
+
+// Returns the next or previous cell in the direction, and whether a line
+// break that is not a wrapped row lies in between. Returns false at the
+// first and last cell.
+func (vt *VT) step(line int, col int, direction int) (int, int, bool, bool) {
+	cells := vt.searchLine(line)
+	if direction > 0 {
+		switch {
+		case col+1 < len(cells):
+			return line, col + 1, false, true
+		case line+1 < vt.primaryScrollback.len()+len(vt.activeScreen):
+			return line + 1, 0, !wraps(cells), true
+		}
+		return line, col, false, false
+	}
+
+	switch {
+	case col > 0:
+		return line, col - 1, false, true
+	case line > 0:
+		prev := vt.searchLine(line - 1)
+		return line - 1, max(len(prev)-1, 0), !wraps(prev), true
+	}
+	return line, col, false, false
+}
+
+// Returns the class of the character at the position for the word motions:
+// 0 for blanks, 1 for letters, digits and underscores and 2 for others
+func (vt *VT) charClass(line int, col int) int {
+	cells := vt.searchLine(line)
+	if col >= len(cells) {
+		return 0
+	}
+	// The cell after a wide character belongs to it
+	if col > 0 && cells[col-1].width == 2 {
+		col -= 1
+	}
+
+	r := cells[col].rune()
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,108 @@
+package tcellterm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyMode_Motions(t *testing.T) {
+	vt := New()
+	vt.Resize(10, 2)
+	printLine(vt, "one two")
+	printLine(vt, "foo.bar_baz")
+	vt.print('$')
+
+	vt.EnterCopyMode()
+	row, col, ok := vt.CopyCursor()
+	assert.True(t, ok)
+	assert.Equal(t, 1, row)
+	assert.Equal(t, 1, col)
+
+	position := func() [2]int {
+		return [2]int{vt.copy.line, vt.copy.col}
+	}
+
+	vt.CopyMove(CopyTop, 1)
+	assert.Equal(t, [2]int{0, 0}, position())
+	assert.Equal(t, 0, vt.scroll)
+
+	// Words are letters or punctuation, a wrapped row does not end them
+	vt.CopyMove(CopyWordForward, 1)
+	assert.Equal(t, [2]int{0, 4}, position())
+	vt.CopyMove(CopyWordForward, 2)
+	assert.Equal(t, [2]int{1, 3}, position())
+	vt.CopyMove(CopyWordForward, 1)
+	assert.Equal(t, [2]int{1, 4}, position())
+	vt.CopyMove(CopyWordForward, 1)
+	assert.Equal(t, [2]int{3, 0}, position())
+	vt.CopyMove(CopyWordBackward, 1)
+	assert.Equal(t, [2]int{1, 4}, position())
+	vt.CopyMove(CopyWordBackward, 2)
+	assert.Equal(t, [2]int{1, 0}, position())
+
+	vt.CopyMove(CopyLineEnd, 1)
+	assert.Equal(t, [2]int{1, 9}, position())
+	vt.CopyMove(CopyDown, 5)
+	assert.Equal(t, [2]int{3, 9}, position())
+	assert.False(t, vt.IsScrolling())
+
+	vt.ExitCopyMode()
+	assert.False(t, vt.IsCopying())
+}
+
+func TestCopyMode_Visual(t *testing.T) {
+	vt := New()
+	vt.Resize(10, 4)
+	printLine(vt, "abc def")
+	printLine(vt, "ghi jkl")
+	vt.print('$')
+	vt.EnterCopyMode()
+	vt.CopyMove(CopyTop, 1)
+	vt.CopyMove(CopyRight, 1)
+
+	vt.SetCopyVisual(VisualChar)
+	vt.CopyMove(CopyDown, 1)
+	assert.Equal(t, "bc def\ngh", vt.Copy())
+
+	vt.SetCopyVisual(VisualLine)
+	assert.Equal(t, "abc def\nghi jkl", vt.Copy())
+
+	vt.SetCopyVisual(VisualBlock)
+	vt.CopyMove(CopyRight, 4)
+	assert.Equal(t, "bc de\nhi jk", vt.Copy())
+
+	vt.SetCopyVisual(VisualBlock)
+	assert.Equal(t, VisualNone, vt.CopyVisual())
+	assert.False(t, vt.HasSelection())
+}
+
+func TestCopyMode_Resize(t *testing.T) {
+	t.Run("Alternate screen", func(t *testing.T) {
+		vt := New()
+		write(vt, "\x1b[?1049h")
+		vt.Resize(3, 2)
+		write(vt, "世界x")
+		vt.EnterCopyMode()
+
+		// The lines of the alternate screen are not reflowed
+		vt.Resize(8, 1)
+		assert.NotPanics(t, func() { vt.SetCopyVisual(VisualBlock) })
+		assert.Less(t, vt.copy.line, vt.primaryScrollback.len()+1)
+	})
+
+	t.Run("Primary screen", func(t *testing.T) {
+		vt := New()
+		vt.Resize(4, 3)
+		write(vt, "\t")
+		vt.Resize(3, 1)
+		vt.Resize(4, 2)
+		vt.EnterCopyMode()
+		vt.CopyMove(CopyWordForward, 1)
+
+		// The reflowed lines past the screen are dropped
+		vt.Resize(5, 2)
+		assert.NotPanics(t, func() { vt.CopyMove(CopyLineEnd, 1) })
+		assert.Less(t, vt.copy.line, vt.primaryScrollback.len()+2)
+	})
+}
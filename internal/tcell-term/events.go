@@ -1,6 +1,7 @@
 package tcellterm
 
 import (
+	"os/exec"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -35,6 +36,13 @@ type EventRedraw struct {
 // EventClosed is emitted when the terminal exits
 type EventClosed struct {
 	*EventTerminal
+	cmd *exec.Cmd
+}
+
+// Cmd returns the command that was running in the terminal. The command has
+// already been waited for, so its ProcessState is set
+func (ev *EventClosed) Cmd() *exec.Cmd {
+	return ev.cmd
 }
 
 // EventTitle is emitted when the terminal's title changes
@@ -0,0 +1,69 @@
+package tcellterm
+
+import (
+	"io"
+	"slices"
+	"sync"
+)
+
+// inputQueue writes the input of the command to its pty in the background and
+// in order, so a command that does not read its input does not hold up the
+// terminal
+type inputQueue struct {
+	mu      sync.Mutex
+	pending [][]byte
+	wake    chan struct{}
+	closed  bool
+}
+
+// Creates a queue writing to w until it is closed
+func newInputQueue(w io.Writer) *inputQueue {
+	q := &inputQueue{wake: make(chan struct{}, 1)}
+	go q.run(w)
+	return q
+}
+
+// Queues the input for the command
+func (q *inputQueue) push(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.pending = append(q.pending, slices.Clone(p))
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stops the queue, the input that was not written yet is dropped
+func (q *inputQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.pending = nil
+	close(q.wake)
+}
+
+// Writes the queued input until the queue is closed
+func (q *inputQueue) run(w io.Writer) {
+	for range q.wake {
+		q.mu.Lock()
+		pending := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+
+		for _, p := range pending {
+			if _, err := w.Write(p); err != nil {
+				break // the pty is closed
+			}
+		}
+	}
+}
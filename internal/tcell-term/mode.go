@@ -88,6 +88,7 @@ func (vt *VT) rm(params []int) {
 }
 
 func (vt *VT) decset(params []int) {
+	defer vt.mouseModeChanged(vt.mode)
 	for _, param := range params {
 		switch param {
 		case 1:
@@ -122,6 +123,7 @@ func (vt *VT) decset(params []int) {
 			vt.decsc()
 			vt.activeScreen = vt.altScreen
 			vt.mode |= smcup
+			vt.clampCopy()
 			// Enable altScroll in the alt screen. This is only used
 			// if the application doesn't enable mouse
 			vt.mode |= altScroll
@@ -132,6 +134,7 @@ func (vt *VT) decset(params []int) {
 }
 
 func (vt *VT) decrst(params []int) {
+	defer vt.mouseModeChanged(vt.mode)
 	for _, param := range params {
 		switch param {
 		case 1:
@@ -171,6 +174,7 @@ func (vt *VT) decrst(params []int) {
 			vt.mode &^= smcup
 			vt.mode &^= altScroll
 			vt.decrc()
+			vt.clampCopy()
 		case 2004:
 			vt.mode &^= paste
 		}
@@ -2,24 +2,73 @@ package tcellterm
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/gdamore/tcell/v2"
 )
 
+// Modes in which the application receives mouse events
+const mouseTracking = mouseButtons | mouseDrag | mouseMotion | mouseSGR
+
+// MouseFlags returns the mouse events the application asked for, none if it
+// does not track the mouse
+func (vt *VT) MouseFlags() []tcell.MouseFlags {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return mouseFlags(vt.mode)
+}
+
+// AlternateScroll returns true if the wheel is sent to the application as
+// arrow keys, as applications on the alternate screen that do not track the
+// mouse expect
+func (vt *VT) AlternateScroll() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.mode&mouseTracking == 0 && vt.mode&altScroll != 0 && vt.mode&smcup != 0
+}
+
+// Returns the mouse events reported in the modes
+func mouseFlags(m mode) []tcell.MouseFlags {
+	if m&mouseTracking == 0 {
+		return nil
+	}
+	flags := []tcell.MouseFlags{tcell.MouseButtonEvents}
+	if m&(mouseDrag|mouseMotion) != 0 {
+		flags = append(flags, tcell.MouseDragEvents)
+	}
+	if m&mouseMotion != 0 {
+		flags = append(flags, tcell.MouseMotionEvents)
+	}
+	return flags
+}
+
+// Posts EventMouseMode if the reported mouse events differ from the ones of
+// the previous modes
+func (vt *VT) mouseModeChanged(before mode) {
+	flags := mouseFlags(vt.mode)
+	if slices.Equal(flags, mouseFlags(before)) {
+		return
+	}
+	vt.postEvent(&EventMouseMode{
+		modes:         flags,
+		EventTerminal: newEventTerminal(vt),
+	})
+}
+
 func (vt *VT) handleMouse(ev *tcell.EventMouse) string {
-	if vt.mode&mouseButtons == 0 && vt.mode&mouseDrag == 0 && vt.mode&mouseMotion == 0 && vt.mode&mouseSGR == 0 {
+	if vt.mode&mouseTracking == 0 {
 		if vt.mode&altScroll != 0 && vt.mode&smcup != 0 {
 			// Translate wheel motion into arrows up and down
 			// 3x rows
 			if ev.Buttons()&tcell.WheelUp != 0 {
-				vt.pty.WriteString(info.KeyUp)
-				vt.pty.WriteString(info.KeyUp)
-				vt.pty.WriteString(info.KeyUp)
+				vt.send(info.KeyUp)
+				vt.send(info.KeyUp)
+				vt.send(info.KeyUp)
 			}
 			if ev.Buttons()&tcell.WheelDown != 0 {
-				vt.pty.WriteString(info.KeyDown)
-				vt.pty.WriteString(info.KeyDown)
-				vt.pty.WriteString(info.KeyDown)
+				vt.send(info.KeyDown)
+				vt.send(info.KeyDown)
+				vt.send(info.KeyDown)
 			}
 		}
 		return ""
@@ -75,3 +75,29 @@ func TestHandleMouse(t *testing.T) {
 		})
 	}
 }
+
+func TestMouseFlags(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	assert.Empty(t, vt.MouseFlags())
+
+	// Enabling the tracking posts an event, changing the encoding does not
+	vt.decset([]int{1002, 1006})
+	assert.Equal(t, []tcell.MouseFlags{tcell.MouseButtonEvents, tcell.MouseDragEvents}, vt.MouseFlags())
+	assert.Len(t, vt.events, 1)
+	ev := (<-vt.events).(*EventMouseMode)
+	assert.Equal(t, vt.MouseFlags(), ev.Flags())
+	vt.decrst([]int{1006})
+	assert.Len(t, vt.events, 0)
+
+	vt.decrst([]int{1002})
+	assert.Empty(t, vt.MouseFlags())
+	assert.Len(t, vt.events, 1)
+
+	// The alternate screen scrolls with the arrow keys unless the mouse is tracked
+	assert.False(t, vt.AlternateScroll())
+	vt.decset([]int{1049})
+	assert.True(t, vt.AlternateScroll())
+	vt.decset([]int{1000})
+	assert.False(t, vt.AlternateScroll())
+}
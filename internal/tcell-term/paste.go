@@ -0,0 +1,27 @@
+package tcellterm
+
+import (
+	"strings"
+)
+
+// Paste sends pasted text to the running command as a single write. Line
+// breaks are sent as carriage returns like a real terminal does, wrapped in
+// bracketed paste markers when the application enabled the paste mode, so
+// shells insert the lines instead of running them one by one.
+func (vt *VT) Paste(text string) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.send(pasteData(text, vt.mode))
+}
+
+// Returns the bytes sent for pasted text in the modes
+func pasteData(text string, m mode) string {
+	text = strings.ReplaceAll(text, "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+	if m&paste == 0 {
+		return text
+	}
+	// The end marker inside the text would let it escape the paste
+	text = strings.ReplaceAll(text, info.PasteEnd, "")
+	return info.PasteStart + text + info.PasteEnd
+}
@@ -0,0 +1,74 @@
+package tcellterm
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasteData(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		mode     mode
+		expected string
+	}{
+		{
+			name:     "plain text",
+			text:     "echo hello",
+			expected: "echo hello",
+		},
+		{
+			name:     "line breaks",
+			text:     "echo a\necho b\r\n",
+			expected: "echo a\recho b\r",
+		},
+		{
+			name:     "bracketed",
+			text:     "echo a\necho b",
+			mode:     paste,
+			expected: "\x1b[200~echo a\recho b\x1b[201~",
+		},
+		{
+			name:     "bracketed with end marker",
+			text:     "a\x1b[201~rm -rf /\n",
+			mode:     paste,
+			expected: "\x1b[200~arm -rf /\r\x1b[201~",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, pasteData(test.text, test.mode))
+		})
+	}
+}
+
+func TestPaste_Unread(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no pty on windows")
+	}
+
+	vt := New()
+	vt.SetSurface(&testSurface{w: 20, h: 5})
+	assert.NoError(t, vt.Start(exec.Command("/bin/sh", "-c", "sleep 60")))
+
+	// The command never reads the paste, the terminal is not held up by it
+	done := make(chan struct{})
+	go func() {
+		vt.Paste(strings.Repeat("echo x\n", 1<<17))
+		vt.Draw()
+		vt.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Paste() blocked on a command that does not read its input")
+	}
+}
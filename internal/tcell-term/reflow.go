@@ -0,0 +1,210 @@
+package tcellterm
+
+import (
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// reflow resizes the screens, wrapping the text of the primary screen and of
+// the scrollback kept in memory again at the new width. Rows joined by the
+// wrapped flag form logical lines, which are split into rows of the new
+// width. The screen starts at the same text, rows that no longer fit on it
+// move to the scrollback. The cursor, the scroll position, the search, the
+// selection, the copy mode and the start of the output matched by Match stay
+// on the same text. Lines spilled to disk keep their width.
+func (vt *VT) reflow(w int, h int) {
+	vt.altScreen = resizeRows(vt.altScreen, w, h)
+	if len(vt.primaryScreen) == 0 || w < 1 || h < 1 {
+		vt.primaryScreen = resizeRows(vt.primaryScreen, w, h)
+		return
+	}
+
+	// The alternate screen keeps the cursor of the primary screen saved
+	cursor := &vt.cursor
+	pendingWrap := vt.lastCol
+	if vt.mode&smcup != 0 {
+		cursor = &vt.primaryState.cursor
+		pendingWrap = false
+	}
+	// A cursor restored after the screen shrank may be below it
+	vt.cursor.row = min(vt.cursor.row, row(len(vt.primaryScreen)-1))
+	vt.primaryState.cursor.row = min(vt.primaryState.cursor.row, row(len(vt.primaryScreen)-1))
+
+	// Rows to reflow: the scrollback in memory and the primary screen down to
+	// the cursor or the last row with content
+	ring := vt.primaryScrollback.memoryLines()
+	first := vt.primaryScrollback.len() - len(ring)
+	used := min(int(cursor.row), len(vt.primaryScreen)-1)
+	for i := len(vt.primaryScreen) - 1; i > used; i -= 1 {
+		if !blankRow(vt.primaryScreen[i]) {
+			used = i
+			break
+		}
+	}
+	rows := append(ring, vt.primaryScreen[:used+1]...)
+
+	// Join the rows into logical lines, remembering where every row starts
+	type origin struct {
+		line   int
+		offset int
+	}
+	origins := make([]origin, len(rows))
+	lines := [][]cell{}
+	current := []cell{}
+	for i, r := range rows {
+		origins[i] = origin{line: len(lines), offset: len(current)}
+		current = append(current, r...)
+		if len(r) == 0 || !r[len(r)-1].wrapped || i == len(rows)-1 {
+			lines = append(lines, current)
+			current = []cell{}
+		}
+	}
+
+	// The line of the cursor keeps the cells up to the cursor
+	cursorRow := len(ring) + int(cursor.row)
+	cursorCol := int(cursor.col)
+	if pendingWrap {
+		cursorCol += 1
+	}
+	cursorLine := origins[cursorRow].line
+	cursorOffset := origins[cursorRow].offset + cursorCol
+
+	// Wrap the logical lines at the new width
+	reflowed := [][]cell{}
+	firstRows := make([]int, len(lines))
+	rowStarts := make([][]int, len(lines))
+	for l, cells := range lines {
+		keep := 0
+		if l == cursorLine {
+			keep = cursorOffset + 1
+		}
+		cells = trimBlank(cells, keep)
+
+		firstRows[l] = len(reflowed)
+		starts := []int{0}
+		next := make([]cell, 0, w)
+		for i, c := range cells {
+			c.wrapped = false
+			// Wide characters do not fit in the last column
+			if len(next) == w || c.width == 2 && len(next) == w-1 && w > 1 {
+				reflowed = append(reflowed, padRow(next, w, true))
+				next = make([]cell, 0, w)
+				starts = append(starts, i)
+			}
+			next = append(next, c)
+		}
+		reflowed = append(reflowed, padRow(next, w, false))
+		rowStarts[l] = starts
+	}
+
+	// Maps a position in the line numbering of the search and the selection
+	locate := func(line int, col int) (int, int) {
+		i := line - first
+		switch {
+		case i < 0:
+			return line, col
+		case i >= len(rows):
+			return first + len(reflowed) + i - len(rows), min(col, w-1)
+		}
+		o := origins[i]
+		offset := o.offset + max(col, 0)
+		starts := rowStarts[o.line]
+		k := sort.Search(len(starts), func(k int) bool { return starts[k] > offset }) - 1
+		return first + firstRows[o.line] + k, min(offset-starts[k], w-1)
+	}
+
+	// The screen starts at the same row unless the rows do not fit, and always
+	// shows the cursor
+	screenStart, _ := locate(first+len(ring), 0)
+	newCursorRow, newCursorCol := locate(first+cursorRow, cursorCol)
+	newCursorRow -= first
+	top := min(max(screenStart-first, len(reflowed)-h), newCursorRow)
+	scrollbackLen := first + top
+
+	anchor := func(scroll int) int {
+		if scroll == -1 {
+			return -1
+		}
+		if scroll, _ = locate(scroll, 0); scroll >= scrollbackLen {
+			return -1
+		}
+		return scroll
+	}
+	vt.scroll = anchor(vt.scroll)
+	vt.mark, _ = locate(vt.mark, 0)
+	if s := vt.search; s != nil {
+		if s.line != -1 {
+			line, start := locate(s.line, s.start)
+			s.line, s.start, s.end = line, start, start+s.end-s.start
+		}
+		s.originLine, _ = locate(s.originLine, 0)
+		s.originScroll = anchor(s.originScroll)
+	}
+	if c := vt.copy; c != nil {
+		c.line, c.col = locate(c.line, c.col)
+		c.anchorLine, c.anchorCol = locate(c.anchorLine, c.anchorCol)
+	}
+	vt.selection.startY, vt.selection.startX = locate(vt.selection.startY, vt.selection.startX)
+	if vt.selection.endY != -1 {
+		vt.selection.endY, vt.selection.endX = locate(vt.selection.endY, vt.selection.endX)
+	}
+
+	cursor.row = row(newCursorRow - top)
+	cursor.col = column(newCursorCol)
+	if cursor == &vt.cursor {
+		vt.lastCol = false
+	}
+
+	vt.primaryScreen = resizeRows(reflowed[top:min(top+h, len(reflowed))], w, h)
+	vt.dropLines(vt.primaryScrollback.replaceMemory(reflowed[:top]))
+}
+
+// Returns the rows cropped or padded with blank cells to the size
+func resizeRows(rows [][]cell, w int, h int) [][]cell {
+	result := make([][]cell, h)
+	for i := range result {
+		result[i] = make([]cell, w)
+		if i < len(rows) {
+			copy(result[i], rows[i])
+		}
+	}
+	return result
+}
+
+// Returns the row padded with blank cells to the width, the wrapped flag is
+// set on its last cell
+func padRow(row []cell, w int, wrapped bool) []cell {
+	for len(row) < w {
+		row = append(row, cell{})
+	}
+	row[w-1].wrapped = wrapped
+	return row
+}
+
+// Removes the trailing blank cells, keeping at least keep cells
+func trimBlank(cells []cell, keep int) []cell {
+	for len(cells) < keep {
+		cells = append(cells, cell{})
+	}
+	n := len(cells)
+	for n > keep && blankCell(cells[n-1]) {
+		n -= 1
+	}
+	return cells[:n]
+}
+
+// Returns true if the cell was never written to
+func blankCell(c cell) bool {
+	return c.content == 0 && c.attrs == tcell.StyleDefault && len(c.combining) == 0
+}
+
+// Returns true if all cells of the row are blank
+func blankRow(cells []cell) bool {
+	for _, c := range cells {
+		if !blankCell(c) {
+			return false
+		}
+	}
+	return true
+}
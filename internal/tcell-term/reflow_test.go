@@ -0,0 +1,120 @@
+package tcellterm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// screenLines returns the text of the rows of the primary screen
+func screenLines(vt *VT) []string {
+	lines := []string{}
+	for _, row := range vt.primaryScreen {
+		lines = append(lines, lineString(row))
+	}
+	return lines
+}
+
+// scrollbackLines returns the text of the scrollback lines
+func scrollbackLines(vt *VT) []string {
+	lines := []string{}
+	for i := range vt.primaryScrollback.len() {
+		lines = append(lines, lineString(vt.primaryScrollback.line(i)))
+	}
+	return lines
+}
+
+func printLine(vt *VT, text string) {
+	for _, r := range text {
+		vt.print(r)
+	}
+	vt.nel()
+}
+
+func TestReflow_Screen(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 3)
+	printLine(vt, "abcdef")
+	vt.print('x')
+
+	// The wrapped line is joined when the screen grows
+	vt.Resize(8, 3)
+	assert.Equal(t, []string{"abcdef", "x", ""}, screenLines(vt))
+	assert.Equal(t, row(1), vt.cursor.row)
+	assert.Equal(t, column(1), vt.cursor.col)
+
+	// And split again when it shrinks, the cursor stays after the same text
+	vt.Resize(3, 3)
+	assert.Equal(t, []string{"abc", "def", "x"}, screenLines(vt))
+	assert.True(t, vt.primaryScreen[0][2].wrapped)
+	assert.False(t, vt.primaryScreen[1][2].wrapped)
+	assert.Equal(t, row(2), vt.cursor.row)
+	assert.Equal(t, column(1), vt.cursor.col)
+}
+
+func TestReflow_Scrollback(t *testing.T) {
+	vt := New()
+	vt.Resize(6, 2)
+	printLine(vt, "one two")
+	printLine(vt, "three")
+	vt.print('$')
+	assert.Equal(t, []string{"one tw", "o"}, scrollbackLines(vt))
+
+	// Rows that do not fit move to the scrollback, rewrapped at the new width
+	vt.Resize(4, 2)
+	assert.Equal(t, []string{"one", "two", "thre"}, scrollbackLines(vt))
+	assert.Equal(t, []string{"e", "$"}, screenLines(vt))
+	assert.Equal(t, row(1), vt.cursor.row)
+
+	// Growing the screen does not bring them back
+	vt.Resize(10, 4)
+	assert.Equal(t, []string{"one two"}, scrollbackLines(vt))
+	assert.Equal(t, []string{"three", "$", "", ""}, screenLines(vt))
+	assert.Equal(t, row(1), vt.cursor.row)
+	assert.Equal(t, column(1), vt.cursor.col)
+}
+
+func TestReflow_Scroll(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 1)
+	for _, text := range []string{"aaaaaa", "bb", "cccccc"} {
+		printLine(vt, text)
+	}
+	vt.ScrollUp(3)
+	assert.Equal(t, "bb", lineString(vt.primaryScrollback.line(vt.scroll)))
+
+	// The view stays on the same line
+	vt.Resize(8, 1)
+	assert.Equal(t, "bb", lineString(vt.primaryScrollback.line(vt.scroll)))
+	vt.Resize(2, 1)
+	assert.Equal(t, "bb", lineString(vt.primaryScrollback.line(vt.scroll)))
+}
+
+// write feeds the text to the terminal as if the command printed it
+func write(vt *VT, text string) {
+	parser := NewParser(strings.NewReader(text))
+	for {
+		seq := parser.Next()
+		if _, ok := seq.(EOF); ok || seq == nil {
+			return
+		}
+		vt.update(seq)
+	}
+}
+
+func TestReflow_RestoredCursorBelowScreen(t *testing.T) {
+	vt := New()
+	vt.SetScrollback(1, 0)
+	vt.Resize(5, 1)
+	write(vt, "ab cd")
+	vt.Resize(2, 3)
+	write(vt, "\x1b[?1049h")
+	write(vt, "\x1b[?1049l")
+	vt.Resize(4, 2)
+
+	// A stray rmcup restores the cursor saved when the screen was higher
+	write(vt, "\x1b[?1049l")
+	assert.NotPanics(t, func() { vt.Resize(7, 2) })
+	assert.Less(t, int(vt.cursor.row), 2)
+}
@@ -0,0 +1,537 @@
+package tcellterm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// DefaultScrollback is the number of scrollback lines kept in memory unless
+// configured otherwise
+const DefaultScrollback = 10000
+
+// Number of lines written to a single file when spilling to disk
+const spillSegmentLines = 1000
+
+// line is the compact form of a row that scrolled off the screen. Trailing
+// blank cells are not stored, and styles are stored once per run of cells
+// as an index into the style palette of the scrollback. The palette counts
+// the runs using each style, so the styles of removed lines are reused.
+type line struct {
+	runes     []rune // content of the cells, zero for cells never written to
+	spans     []styleSpan
+	combining []combiningMarks // nil for most lines
+	cols      int              // width of the row including the trailing blank cells
+	wrapped   bool             // the row continues on the next one
+}
+
+// styleSpan is a run of cells sharing the same style
+type styleSpan struct {
+	cells int
+	style int
+}
+
+// combiningMarks holds the combining characters of a single cell
+type combiningMarks struct {
+	col   int
+	runes []rune
+}
+
+// scrollback stores the rows that scrolled off the primary screen in a ring
+// buffer of compact lines. When the buffer is full the oldest lines are
+// dropped, or moved to compressed files on disk if spilling is enabled.
+// Lines are numbered from the oldest one still available.
+type scrollback struct {
+	styles     []tcell.Style
+	styleIndex map[tcell.Style]int
+	styleRefs  []int // number of spans using each style, including the spilled ones
+	freeStyles []int // indexes of the styles no span uses
+
+	ring  []line // grows up to limit, then the oldest line is overwritten
+	start int    // index of the oldest line in ring
+	limit int
+
+	spill *spill // nil when the oldest lines are dropped
+}
+
+func newScrollback() *scrollback {
+	return &scrollback{
+		styleIndex: map[tcell.Style]int{},
+		limit:      DefaultScrollback,
+	}
+}
+
+// Returns the number of available lines
+func (s *scrollback) len() int {
+	if s.spill != nil {
+		return s.spill.len() + len(s.ring)
+	}
+	return len(s.ring)
+}
+
+// Returns the cells of the line
+func (s *scrollback) line(i int) []cell {
+	if s.spill != nil {
+		spilled := s.spill.len()
+		if i < spilled {
+			return s.expand(s.spill.line(i))
+		}
+		i -= spilled
+	}
+	return s.expand(s.ring[(s.start+i)%len(s.ring)])
+}
+
+// Appends a row and returns the number of lines dropped from the start
+func (s *scrollback) push(cells []cell) int {
+	l := s.compact(cells)
+	if len(s.ring) < s.limit {
+		s.ring = append(s.ring, l)
+		return 0
+	}
+
+	oldest := s.ring[s.start]
+	s.ring[s.start] = l
+	s.start = (s.start + 1) % len(s.ring)
+	return s.evict([]line{oldest})
+}
+
+// Changes the number of lines kept in memory and on disk, and returns the
+// number of lines dropped from the start
+func (s *scrollback) setLimits(lines int, spilled int) int {
+	if lines <= 0 {
+		lines = DefaultScrollback
+	}
+
+	ordered := append(slices.Clone(s.ring[s.start:]), s.ring[:s.start]...)
+	s.start = 0
+	s.limit = lines
+
+	dropped := 0
+	switch {
+	case spilled > 0 && s.spill == nil:
+		s.spill = &spill{limit: spilled, release: s.releaseStyle}
+	case spilled > 0:
+		s.spill.limit = spilled
+		dropped += s.spill.trim()
+	case s.spill != nil:
+		dropped += s.spill.len()
+		s.spill.close()
+		s.spill = nil
+	}
+
+	if excess := len(ordered) - lines; excess > 0 {
+		dropped += s.evict(ordered[:excess])
+		ordered = ordered[excess:]
+	}
+	s.ring = ordered
+	return dropped
+}
+
+// Returns the cells of the lines kept in memory
+func (s *scrollback) memoryLines() [][]cell {
+	rows := make([][]cell, 0, len(s.ring))
+	for i := range s.ring {
+		rows = append(rows, s.expand(s.ring[(s.start+i)%len(s.ring)]))
+	}
+	return rows
+}
+
+// Replaces the lines kept in memory, returns the number of lines dropped from
+// the start if there are more rows than the limit
+func (s *scrollback) replaceMemory(rows [][]cell) int {
+	// The styles of the old lines are released once the new ones use them
+	old := s.ring
+	defer s.release(old)
+	s.ring = make([]line, 0, min(len(rows), s.limit))
+	s.start = 0
+
+	dropped := 0
+	if excess := len(rows) - s.limit; excess > 0 {
+		evicted := make([]line, 0, excess)
+		for _, r := range rows[:excess] {
+			evicted = append(evicted, s.compact(r))
+		}
+		dropped = s.evict(evicted)
+		rows = rows[excess:]
+	}
+	for _, r := range rows {
+		s.ring = append(s.ring, s.compact(r))
+	}
+	return dropped
+}
+
+// Spills or drops lines removed from memory, returns the number of lines dropped
+func (s *scrollback) evict(lines []line) int {
+	if s.spill == nil {
+		s.release(lines)
+		return len(lines)
+	}
+	return s.spill.add(lines)
+}
+
+// Removes all lines and the spilled files
+func (s *scrollback) clear() {
+	s.ring = nil
+	s.start = 0
+	if s.spill != nil {
+		s.spill.close()
+	}
+	s.styles, s.styleRefs, s.freeStyles = nil, nil, nil
+	s.styleIndex = map[tcell.Style]int{}
+}
+
+// Converts a row to its compact form
+func (s *scrollback) compact(cells []cell) line {
+	n := len(cells)
+	for n > 0 && cells[n-1].content == 0 && cells[n-1].attrs == tcell.StyleDefault && len(cells[n-1].combining) == 0 {
+		n -= 1
+	}
+
+	l := line{runes: make([]rune, n), cols: len(cells)}
+	for i, c := range cells {
+		if c.wrapped {
+			l.wrapped = true
+		}
+		if i >= n {
+			continue
+		}
+
+		l.runes[i] = c.content
+		style := s.styleID(c.attrs)
+		if last := len(l.spans) - 1; last >= 0 && l.spans[last].style == style {
+			l.spans[last].cells += 1
+		} else {
+			l.spans = append(l.spans, styleSpan{cells: 1, style: style})
+			s.styleRefs[style] += 1
+		}
+		if len(c.combining) > 0 {
+			l.combining = append(l.combining, combiningMarks{col: i, runes: slices.Clone(c.combining)})
+		}
+	}
+	return l
+}
+
+// Converts a compact line back to a row of cells
+func (s *scrollback) expand(l line) []cell {
+	cells := make([]cell, l.cols)
+	col := 0
+	for _, span := range l.spans {
+		style := s.styles[span.style]
+		for range span.cells {
+			cells[col].content = l.runes[col]
+			cells[col].attrs = style
+			if l.runes[col] != 0 {
+				cells[col].width = runewidth.RuneWidth(l.runes[col])
+			}
+			col += 1
+		}
+	}
+	for _, marks := range l.combining {
+		cells[marks.col].combining = slices.Clone(marks.runes)
+	}
+	if l.wrapped && l.cols > 0 {
+		cells[l.cols-1].wrapped = true
+	}
+	return cells
+}
+
+// Returns the index of the style in the palette, adding it if needed in
+// place of an unused style
+func (s *scrollback) styleID(style tcell.Style) int {
+	id, ok := s.styleIndex[style]
+	switch {
+	case ok:
+	case len(s.freeStyles) > 0:
+		id = s.freeStyles[len(s.freeStyles)-1]
+		s.freeStyles = s.freeStyles[:len(s.freeStyles)-1]
+		s.styles[id] = style
+		s.styleIndex[style] = id
+	default:
+		id = len(s.styles)
+		s.styles = append(s.styles, style)
+		s.styleRefs = append(s.styleRefs, 0)
+		s.styleIndex[style] = id
+	}
+	return id
+}
+
+// Releases the styles of removed lines
+func (s *scrollback) release(lines []line) {
+	for _, l := range lines {
+		for _, span := range l.spans {
+			s.releaseStyle(span.style, 1)
+		}
+	}
+}
+
+// Releases a style used by n removed spans, it is reused once no span uses it
+func (s *scrollback) releaseStyle(id int, n int) {
+	if s.styleRefs[id] -= n; s.styleRefs[id] > 0 {
+		return
+	}
+	delete(s.styleIndex, s.styles[id])
+	s.freeStyles = append(s.freeStyles, id)
+}
+
+// spill keeps the oldest scrollback lines in gzip compressed files of up to
+// spillSegmentLines lines each, in a temporary directory created on demand.
+// Lines wait in memory until a file is full.
+type spill struct {
+	limit    int // maximum number of lines in files, whole files are removed when exceeded
+	dir      string
+	segments []spillSegment
+	pending  []line
+	next     int // number of the next file
+
+	release func(style int, n int) // releases the styles of removed lines
+
+	// Lines of the last file read
+	cached      string
+	cachedLines []line
+}
+
+// spillSegment is a file holding spilled lines
+type spillSegment struct {
+	path   string
+	lines  int
+	styles map[int]int // number of spans using each style, released when the file is removed
+}
+
+// Returns the number of spilled lines
+func (s *spill) len() int {
+	n := len(s.pending)
+	for _, segment := range s.segments {
+		n += segment.lines
+	}
+	return n
+}
+
+// Returns a spilled line
+func (s *spill) line(i int) line {
+	for _, segment := range s.segments {
+		if i >= segment.lines {
+			i -= segment.lines
+			continue
+		}
+		if s.cached != segment.path {
+			lines, err := readSegment(segment.path)
+			if err != nil || len(lines) != segment.lines {
+				// The file is gone or damaged, show blank lines instead
+				lines = make([]line, segment.lines)
+			}
+			s.cached, s.cachedLines = segment.path, lines
+		}
+		return s.cachedLines[i]
+	}
+	return s.pending[i]
+}
+
+// Adds lines after the last spilled one and returns the number of lines
+// dropped from the start
+func (s *spill) add(lines []line) int {
+	s.pending = append(s.pending, lines...)
+	size := s.segmentLines()
+	for len(s.pending) >= size {
+		segment, err := s.write(s.pending[:size])
+		if err != nil {
+			// The disk is not usable, only keep the newest lines in memory
+			dropped := s.len() - (size - 1)
+			s.removeSegments(len(s.segments))
+			s.releaseLines(s.pending[:len(s.pending)-(size-1)])
+			s.pending = slices.Clone(s.pending[len(s.pending)-(size-1):])
+			return dropped
+		}
+		s.segments = append(s.segments, segment)
+		s.pending = slices.Clone(s.pending[size:])
+	}
+	return s.trim()
+}
+
+// Removes the oldest files while the files hold more lines than the limit,
+// returns the number of lines removed. The lines waiting for a file are not
+// counted.
+func (s *spill) trim() int {
+	n := 0
+	written := s.len() - len(s.pending)
+	for n < len(s.segments) && written > s.limit {
+		written -= s.segments[n].lines
+		n += 1
+	}
+	return s.removeSegments(n)
+}
+
+// Removes the n oldest files and returns the number of lines they held
+func (s *spill) removeSegments(n int) int {
+	removed := 0
+	for _, segment := range s.segments[:n] {
+		_ = os.Remove(segment.path)
+		removed += segment.lines
+		for style, spans := range segment.styles {
+			s.release(style, spans)
+		}
+	}
+	s.segments = s.segments[n:]
+	return removed
+}
+
+// Releases the styles of lines removed from memory
+func (s *spill) releaseLines(lines []line) {
+	for _, l := range lines {
+		for _, span := range l.spans {
+			s.release(span.style, 1)
+		}
+	}
+}
+
+// Returns the number of lines per file, small limits use smaller files
+func (s *spill) segmentLines() int {
+	return max(min(spillSegmentLines, s.limit), 1)
+}
+
+// Writes the lines to a new file
+func (s *spill) write(lines []line) (spillSegment, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp("", "multiplexer-scrollback-")
+		if err != nil {
+			return spillSegment{}, err
+		}
+		s.dir = dir
+	}
+
+	path := filepath.Join(s.dir, strconv.Itoa(s.next)+".gz")
+	s.next += 1
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(encodeLines(lines)); err != nil {
+		return spillSegment{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return spillSegment{}, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return spillSegment{}, err
+	}
+
+	styles := map[int]int{}
+	for _, l := range lines {
+		for _, span := range l.spans {
+			styles[span.style] += 1
+		}
+	}
+	return spillSegment{path: path, lines: len(lines), styles: styles}, nil
+}
+
+// Removes the spilled files
+func (s *spill) close() {
+	s.removeSegments(len(s.segments))
+	s.releaseLines(s.pending)
+	if s.dir != "" {
+		_ = os.RemoveAll(s.dir)
+	}
+	s.dir = ""
+	s.segments = nil
+	s.pending = nil
+	s.cached, s.cachedLines = "", nil
+}
+
+// Reads the lines of a spilled file
+func readSegment(path string) ([]line, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	return decodeLines(data)
+}
+
+// Serializes lines as unsigned varints, the styles are palette indexes
+func encodeLines(lines []line) []byte {
+	var buf []byte
+	put := func(v int) {
+		buf = binary.AppendUvarint(buf, uint64(v))
+	}
+	putRunes := func(runes []rune) {
+		put(len(runes))
+		for _, r := range runes {
+			put(int(r))
+		}
+	}
+
+	put(len(lines))
+	for _, l := range lines {
+		put(l.cols)
+		if l.wrapped {
+			put(1)
+		} else {
+			put(0)
+		}
+		putRunes(l.runes)
+		put(len(l.spans))
+		for _, span := range l.spans {
+			put(span.cells)
+			put(span.style)
+		}
+		put(len(l.combining))
+		for _, marks := range l.combining {
+			put(marks.col)
+			putRunes(marks.runes)
+		}
+	}
+	return buf
+}
+
+// Deserializes lines written by encodeLines
+func decodeLines(data []byte) ([]line, error) {
+	r := bytes.NewReader(data)
+	var err error
+	get := func() int {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = binary.ReadUvarint(r)
+		return int(v)
+	}
+	getRunes := func() []rune {
+		runes := make([]rune, get())
+		for i := range runes {
+			runes[i] = rune(get())
+		}
+		return runes
+	}
+
+	n := get()
+	lines := make([]line, 0, n)
+	for i := 0; i < n && err == nil; i += 1 {
+		l := line{cols: get(), wrapped: get() == 1, runes: getRunes()}
+		for range get() {
+			l.spans = append(l.spans, styleSpan{cells: get(), style: get()})
+		}
+		for range get() {
+			l.combining = append(l.combining, combiningMarks{col: get(), runes: getRunes()})
+		}
+		lines = append(lines, l)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,150 @@
+package tcellterm
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// textRow returns a row of the given width starting with the text
+func textRow(text string, width int) []cell {
+	row := make([]cell, width)
+	for i, r := range []rune(text) {
+		row[i] = cell{content: r, width: 1}
+	}
+	return row
+}
+
+func TestScrollback_Compact(t *testing.T) {
+	s := newScrollback()
+	red := tcell.StyleDefault.Foreground(tcell.ColorRed)
+
+	row := textRow("e ab", 8)
+	row[0].combining = []rune{'́'}
+	row[2].attrs = red
+	row[3].attrs = red
+	row[7].wrapped = true
+	s.push(row)
+
+	l := s.ring[0]
+	assert.Equal(t, []rune{'e', ' ', 'a', 'b'}, l.runes)
+	assert.Equal(t, []styleSpan{{cells: 2, style: 0}, {cells: 2, style: 1}}, l.spans)
+	assert.Equal(t, 8, l.cols)
+	assert.True(t, l.wrapped)
+
+	got := s.line(0)
+	assert.Len(t, got, 8)
+	assert.Equal(t, "e\u0301 ab", lineString(got))
+	assert.Equal(t, red, got[3].attrs)
+	assert.Equal(t, tcell.StyleDefault, got[4].attrs)
+	assert.True(t, got[7].wrapped)
+}
+
+func TestScrollback_Limit(t *testing.T) {
+	vt := New()
+	vt.Resize(8, 2)
+	vt.SetScrollback(3, 0)
+	for i := range 5 {
+		vt.primaryScrollback.push(textRow("line "+strconv.Itoa(i), 8))
+	}
+
+	assert.Equal(t, 3, vt.primaryScrollback.len())
+	assert.Equal(t, "line 2", lineString(vt.primaryScrollback.line(0)))
+	assert.Equal(t, "line 4", lineString(vt.primaryScrollback.line(2)))
+
+	// The view stays on the same line while old lines are dropped
+	vt.ScrollUp(1)
+	assert.Equal(t, "line 4", lineString(vt.primaryScrollback.line(vt.scroll)))
+	vt.scrollUp(1)
+	assert.Equal(t, "line 4", lineString(vt.primaryScrollback.line(vt.scroll)))
+
+	// Lowering the limit keeps the newest lines
+	vt.SetScrollback(1, 0)
+	assert.Equal(t, 1, vt.primaryScrollback.len())
+	assert.Equal(t, 0, vt.scroll)
+}
+
+func TestScrollback_Spill(t *testing.T) {
+	s := newScrollback()
+	s.setLimits(10, 2*spillSegmentLines)
+	defer s.clear()
+
+	for i := range 3*spillSegmentLines + 10 + 5 {
+		s.push(textRow(strconv.Itoa(i), 8))
+	}
+
+	// Two files on disk, the oldest one was removed, and the lines waiting for a file
+	assert.Len(t, s.spill.segments, 2)
+	assert.Len(t, s.spill.pending, 5)
+	assert.Equal(t, 2*spillSegmentLines+5+10, s.len())
+
+	for _, i := range []int{0, spillSegmentLines - 1, spillSegmentLines, 2*spillSegmentLines + 4, s.len() - 1} {
+		assert.Equal(t, strconv.Itoa(i+spillSegmentLines), lineString(s.line(i)))
+	}
+
+	// Lowering the limit removes the files above it, small limits use smaller files
+	s.setLimits(10, 300)
+	assert.Empty(t, s.spill.segments)
+	for i := range 300 {
+		s.push(textRow(strconv.Itoa(i), 8))
+	}
+	assert.Len(t, s.spill.segments, 1)
+	assert.Equal(t, 300, s.spill.segments[0].lines)
+
+	dir := s.spill.dir
+	s.clear()
+	assert.Equal(t, 0, s.len())
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestScrollback_StylePalette(t *testing.T) {
+	styled := func(i int) []cell {
+		row := textRow(strconv.Itoa(i), 8)
+		row[0].attrs = tcell.StyleDefault.Foreground(tcell.NewHexColor(int32(i)))
+		return row
+	}
+
+	// Styles of dropped lines are reused
+	s := newScrollback()
+	s.setLimits(10, 0)
+	for i := range 1000 {
+		s.push(styled(i))
+	}
+	assert.LessOrEqual(t, len(s.styles), 12)
+	assert.Equal(t, 11, len(s.styleIndex))
+	assert.Equal(t, "999", lineString(s.line(9)))
+	assert.Equal(t, tcell.StyleDefault.Foreground(tcell.NewHexColor(999)), s.line(9)[0].attrs)
+
+	// Styles of spilled lines are kept until their file is removed
+	s.setLimits(10, 20)
+	defer s.clear()
+	for i := range 1000 {
+		s.push(styled(i))
+	}
+	assert.LessOrEqual(t, len(s.styles), 10+2*20+2)
+	assert.Equal(t, tcell.StyleDefault.Foreground(tcell.NewHexColor(975)), s.line(5)[0].attrs)
+
+	s.clear()
+	assert.Empty(t, s.styles)
+	assert.Empty(t, s.styleIndex)
+}
+
+func TestEncodeLines(t *testing.T) {
+	lines := []line{
+		{runes: []rune{'a', 0, 'ü'}, spans: []styleSpan{{cells: 3, style: 4}}, cols: 80, wrapped: true},
+		{cols: 80},
+		{runes: []rune{'e'}, spans: []styleSpan{{cells: 1}}, combining: []combiningMarks{{col: 0, runes: []rune{'́'}}}, cols: 2},
+	}
+
+	got, err := decodeLines(encodeLines(lines))
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, lines[0], got[0])
+	assert.Equal(t, 80, got[1].cols)
+	assert.Empty(t, got[1].runes)
+	assert.Equal(t, lines[2].combining, got[2].combining)
+}
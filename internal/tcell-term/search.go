@@ -0,0 +1,255 @@
+package tcellterm
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Styles of the search matches
+var (
+	matchStyle        = tcell.StyleDefault.Background(tcell.ColorYellow).Foreground(tcell.ColorBlack)
+	currentMatchStyle = tcell.StyleDefault.Background(tcell.ColorOrange).Foreground(tcell.ColorBlack)
+)
+
+// search holds the state of a search over the scrollback and the active
+// screen. Lines are numbered from the oldest scrollback line, the lines of
+// the active screen follow the scrollback.
+type search struct {
+	re       *regexp.Regexp
+	backward bool
+
+	// View position when the search started, refined patterns search from it
+	originScroll int
+	originLine   int
+	originCol    int
+
+	// Current match, line is -1 when there is none
+	line  int
+	start int
+	end   int
+
+	index int
+	total int
+}
+
+// Search highlights the matches of the regular expression in the scrollback
+// and the active screen, and scrolls to the first match after the view
+// position where the search started, or before it if backward is set. The
+// search wraps around. Returns false if nothing matches.
+func (vt *VT) Search(re *regexp.Regexp, backward bool) bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if vt.search == nil {
+		vt.search = &search{originScroll: vt.scroll}
+		top := vt.scroll
+		if top == -1 {
+			top = vt.primaryScrollback.len()
+		}
+		vt.search.originLine = top
+		if backward {
+			vt.search.originLine = top + vt.height() - 1
+			vt.search.originCol = math.MaxInt
+		}
+	}
+
+	vt.search.re = re
+	vt.search.backward = backward
+	vt.search.line = -1
+	return vt.findMatch(vt.search.originLine, vt.search.originCol, backward, true)
+}
+
+// SearchNext moves to the next match in the search direction, or to the
+// previous one if reverse is set. Returns false if nothing matches.
+func (vt *VT) SearchNext(reverse bool) bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if vt.search == nil {
+		return false
+	}
+
+	backward := vt.search.backward != reverse
+	if vt.search.line == -1 {
+		return vt.findMatch(vt.search.originLine, vt.search.originCol, backward, true)
+	}
+	return vt.findMatch(vt.search.line, vt.search.start, backward, false)
+}
+
+// SearchResult returns the position of the current match and the number of
+// matches, the position is zero when there is no current match
+func (vt *VT) SearchResult() (int, int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if vt.search == nil {
+		return 0, 0
+	}
+	return vt.search.index, vt.search.total
+}
+
+// IsSearching reports whether search matches are highlighted
+func (vt *VT) IsSearching() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.search != nil
+}
+
+// ClearSearch removes the search highlighting and keeps the scroll position
+func (vt *VT) ClearSearch() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.search = nil
+}
+
+// AbortSearch removes the search highlighting and restores the scroll
+// position from before the search
+func (vt *VT) AbortSearch() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if vt.search != nil {
+		vt.scroll = vt.search.originScroll
+		vt.search = nil
+	}
+}
+
+// Moves to the first match after the given position, or before it when
+// searching backward, wrapping around at the ends. The match at the position
+// itself counts only if inclusive is set.
+func (vt *VT) findMatch(line int, col int, backward bool, inclusive bool) bool {
+	lines := vt.primaryScrollback.len() + len(vt.activeScreen)
+	line = min(max(line, 0), lines-1)
+
+	for i := 0; i <= lines && lines > 0; i += 1 {
+		current := line + i
+		if backward {
+			current = line - i
+		}
+		current = ((current % lines) + lines) % lines
+
+		matches := vt.search.matches(vt.searchLine(current))
+		if backward {
+			for m := len(matches) - 1; m >= 0; m -= 1 {
+				if i > 0 || matches[m][0] < col || inclusive && matches[m][0] == col {
+					vt.selectMatch(current, matches[m])
+					return true
+				}
+			}
+		} else {
+			for _, match := range matches {
+				if i > 0 || match[0] > col || inclusive && match[0] == col {
+					vt.selectMatch(current, match)
+					return true
+				}
+			}
+		}
+	}
+
+	vt.search.line = -1
+	vt.search.index = 0
+	vt.search.total = 0
+	return false
+}
+
+// Makes the match current, scrolls to it and counts the matches
+func (vt *VT) selectMatch(line int, match [2]int) {
+	vt.search.line = line
+	vt.search.start = match[0]
+	vt.search.end = match[1]
+
+	vt.search.index = 0
+	vt.search.total = 0
+	for i := 0; i < vt.primaryScrollback.len()+len(vt.activeScreen); i += 1 {
+		for _, m := range vt.search.matches(vt.searchLine(i)) {
+			vt.search.total += 1
+			if i < line || i == line && m[0] <= match[0] {
+				vt.search.index += 1
+			}
+		}
+	}
+
+	vt.reveal(line)
+}
+
+// Scrolls the view so that the line is visible, centering scrollback lines if needed
+func (vt *VT) reveal(line int) {
+	top := vt.scroll
+	if top == -1 {
+		top = vt.primaryScrollback.len()
+	}
+	if line >= top && line < top+vt.height() {
+		return
+	}
+
+	// Lines of the active screen are all visible without scrolling
+	if line >= vt.primaryScrollback.len() {
+		vt.resetScroll()
+		return
+	}
+	vt.scroll = max(line-vt.height()/2, 0)
+}
+
+// Returns a line of the scrollback or of the active screen
+func (vt *VT) searchLine(line int) []cell {
+	if line < vt.primaryScrollback.len() {
+		return vt.primaryScrollback.line(line)
+	}
+	return vt.activeScreen[line-vt.primaryScrollback.len()]
+}
+
+// Moves the line numbers after n lines were dropped from the start of the scrollback
+func (s *search) drop(n int) {
+	if s.originScroll != -1 {
+		s.originScroll = max(s.originScroll-n, 0)
+	}
+	s.originLine = max(s.originLine-n, 0)
+	if s.line != -1 {
+		if s.line -= n; s.line < 0 {
+			s.line = -1
+		}
+	}
+}
+
+// Returns the column ranges of the non-empty matches in the line
+func (s *search) matches(line []cell) [][2]int {
+	// Column of every byte of the text
+	text := strings.Builder{}
+	columns := []int{}
+	for col := range line {
+		before := text.Len()
+		_, _ = text.WriteRune(line[col].rune())
+		for _, comb := range line[col].combining {
+			_, _ = text.WriteRune(comb)
+		}
+		for range text.Len() - before {
+			columns = append(columns, col)
+		}
+	}
+	str := strings.TrimRight(text.String(), " ")
+
+	result := [][2]int{}
+	for _, loc := range s.re.FindAllStringIndex(str, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		result = append(result, [2]int{columns[loc[0]], columns[loc[1]-1] + 1})
+	}
+	return result
+}
+
+// Returns the style of a cell with the search match highlighting applied
+func (s *search) style(line int, col int, matches [][2]int, style tcell.Style) tcell.Style {
+	if line == s.line && col >= s.start && col < s.end {
+		return currentMatchStyle
+	}
+	for _, match := range matches {
+		if col >= match[0] && col < match[1] {
+			return matchStyle
+		}
+	}
+	return style
+}
@@ -0,0 +1,98 @@
+package tcellterm
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSearchVT returns a terminal with the lines in its scrollback and "error
+// three" followed by "ok" on its screen
+func newSearchVT(lines ...string) *VT {
+	vt := New()
+	vt.Resize(16, 2)
+	for _, line := range lines {
+		row := make([]cell, 16)
+		for i, r := range line {
+			row[i].content = r
+		}
+		vt.primaryScrollback.push(row)
+	}
+	for _, r := range "error three" {
+		vt.print(r)
+	}
+	vt.nel()
+	for _, r := range "ok" {
+		vt.print(r)
+	}
+	return vt
+}
+
+func TestSearch(t *testing.T) {
+	vt := newSearchVT("error one", "fine", "error two", "fine", "fine")
+
+	// Searching backward from the bottom finds the match on the screen first
+	assert.True(t, vt.Search(regexp.MustCompile(`error`), true))
+	index, total := vt.SearchResult()
+	assert.Equal(t, 3, index)
+	assert.Equal(t, 3, total)
+	assert.False(t, vt.IsScrolling())
+
+	// n continues backward and scrolls to the match
+	assert.True(t, vt.SearchNext(false))
+	index, _ = vt.SearchResult()
+	assert.Equal(t, 2, index)
+	assert.Equal(t, 1, vt.scroll)
+
+	assert.True(t, vt.SearchNext(false))
+	index, _ = vt.SearchResult()
+	assert.Equal(t, 1, index)
+	assert.Equal(t, 0, vt.scroll)
+
+	// The search wraps around at the top
+	assert.True(t, vt.SearchNext(false))
+	index, _ = vt.SearchResult()
+	assert.Equal(t, 3, index)
+	assert.False(t, vt.IsScrolling())
+
+	// N goes the other way
+	assert.True(t, vt.SearchNext(true))
+	index, _ = vt.SearchResult()
+	assert.Equal(t, 1, index)
+}
+
+func TestSearch_Refine(t *testing.T) {
+	vt := newSearchVT("error one", "fine", "error two")
+
+	assert.True(t, vt.Search(regexp.MustCompile(`e`), true))
+	assert.True(t, vt.Search(regexp.MustCompile(`error t`), true))
+	index, total := vt.SearchResult()
+	assert.Equal(t, 2, index)
+	assert.Equal(t, 2, total)
+
+	assert.False(t, vt.Search(regexp.MustCompile(`panic`), true))
+	index, total = vt.SearchResult()
+	assert.Equal(t, 0, index)
+	assert.Equal(t, 0, total)
+}
+
+func TestSearch_Abort(t *testing.T) {
+	vt := newSearchVT("error one", "fine", "fine", "fine")
+
+	assert.True(t, vt.Search(regexp.MustCompile(`one`), false))
+	assert.Equal(t, 0, vt.scroll)
+
+	vt.AbortSearch()
+	assert.False(t, vt.IsSearching())
+	assert.False(t, vt.IsScrolling())
+}
+
+func TestSearch_Matches(t *testing.T) {
+	vt := newSearchVT()
+	s := &search{re: regexp.MustCompile(`e`)}
+	assert.Equal(t, [][2]int{{0, 1}, {9, 10}, {10, 11}}, s.matches(vt.activeScreen[0]))
+
+	s = &search{re: regexp.MustCompile(`\s*$`)}
+	assert.Equal(t, [][2]int{}, s.matches(vt.activeScreen[1]))
+}
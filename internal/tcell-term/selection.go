@@ -0,0 +1,168 @@
+package tcellterm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Characters that end a word selected by SelectWord, besides spaces
+const wordSeparators = "\"'`()[]{}<>|,;│"
+
+// Copy returns the selected text. Rows continued on the next one are joined,
+// other rows end with a newline. Block selections copy the same columns of
+// every row.
+func (vt *VT) Copy() string {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if !vt.hasSelection() {
+		return ""
+	}
+
+	startX, startY, endX, endY := vt.selection.startX, vt.selection.startY, vt.selection.endX, vt.selection.endY
+	if endY < startY || endY == startY && endX < startX {
+		startX, startY, endX, endY = endX, endY, startX, startY
+	}
+
+	text := strings.Builder{}
+	total := vt.primaryScrollback.len() + len(vt.activeScreen)
+	for line := max(startY, 0); line <= min(endY, total-1); line += 1 {
+		cells := vt.searchLine(line)
+		from, to := 0, len(cells)-1
+		switch {
+		case vt.selection.block:
+			from, to = min(startX, endX), min(max(startX, endX), to)
+		default:
+			if line == startY {
+				from = startX
+			}
+			if line == endY {
+				to = min(endX, to)
+			}
+		}
+
+		row := strings.Builder{}
+		for col := from; col <= to; col += 1 {
+			// The cell after a wide character is covered by it
+			if col > 0 && cells[col-1].width == 2 {
+				continue
+			}
+			_, _ = row.WriteRune(cells[col].rune())
+			for _, comb := range cells[col].combining {
+				_, _ = row.WriteRune(comb)
+			}
+		}
+		if !vt.selection.block && wraps(cells) && to == len(cells)-1 {
+			text.WriteString(row.String())
+			continue
+		}
+		text.WriteString(strings.TrimRight(row.String(), " "))
+		text.WriteRune('\n')
+	}
+	return strings.TrimRightFunc(text.String(), unicode.IsSpace)
+}
+
+// SelectWord selects the word at the position of the view, following it
+// over wrapped rows. Outside of a word only the cell is selected.
+func (vt *VT) SelectWord(x int, y int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	line, ok := vt.viewLine(y)
+	if !ok {
+		return
+	}
+
+	cells := vt.searchLine(line)
+	if len(cells) == 0 {
+		return
+	}
+	x = min(x, len(cells)-1)
+	vt.selection = &selection{startX: x, startY: line, endX: x, endY: line}
+	if !inWord(cells, x) {
+		return
+	}
+
+	for {
+		cells := vt.searchLine(vt.selection.startY)
+		if vt.selection.startX > 0 && inWord(cells, vt.selection.startX-1) {
+			vt.selection.startX -= 1
+			continue
+		}
+		if vt.selection.startX == 0 && vt.selection.startY > 0 {
+			prev := vt.searchLine(vt.selection.startY - 1)
+			if wraps(prev) && inWord(prev, len(prev)-1) {
+				vt.selection.startY -= 1
+				vt.selection.startX = len(prev) - 1
+				continue
+			}
+		}
+		break
+	}
+
+	total := vt.primaryScrollback.len() + len(vt.activeScreen)
+	for {
+		cells := vt.searchLine(vt.selection.endY)
+		if vt.selection.endX < len(cells)-1 && inWord(cells, vt.selection.endX+1) {
+			vt.selection.endX += 1
+			continue
+		}
+		if vt.selection.endX == len(cells)-1 && wraps(cells) && vt.selection.endY+1 < total {
+			next := vt.searchLine(vt.selection.endY + 1)
+			if inWord(next, 0) {
+				vt.selection.endY += 1
+				vt.selection.endX = 0
+				continue
+			}
+		}
+		break
+	}
+}
+
+// SelectLine selects the line at the row of the view, including the rows it
+// wraps over
+func (vt *VT) SelectLine(y int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	line, ok := vt.viewLine(y)
+	if !ok {
+		return
+	}
+
+	start, end := line, line
+	for start > 0 && wraps(vt.searchLine(start-1)) {
+		start -= 1
+	}
+	total := vt.primaryScrollback.len() + len(vt.activeScreen)
+	for end+1 < total && wraps(vt.searchLine(end)) {
+		end += 1
+	}
+	vt.selection = &selection{
+		startX: 0,
+		startY: start,
+		endX:   max(len(vt.searchLine(end))-1, 0),
+		endY:   end,
+	}
+}
+
+// Returns the line shown at the row of the view, false if there is none
+func (vt *VT) viewLine(y int) (int, bool) {
+	line := vt.viewTop() + y
+	if y < 0 || line >= vt.primaryScrollback.len()+len(vt.activeScreen) {
+		return 0, false
+	}
+	return line, true
+}
+
+// Returns true if the row continues on the next one
+func wraps(cells []cell) bool {
+	return len(cells) > 0 && cells[len(cells)-1].wrapped
+}
+
+// Returns true if the cell is part of a word
+func inWord(cells []cell, col int) bool {
+	// The cell after a wide character belongs to it
+	if col > 0 && cells[col-1].width == 2 {
+		col -= 1
+	}
+	r := cells[col].rune()
+	return !unicode.IsSpace(r) && !strings.ContainsRune(wordSeparators, r)
+}
@@ -0,0 +1,56 @@
+package tcellterm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectWord(t *testing.T) {
+	vt := New()
+	vt.Resize(8, 3)
+	printLine(vt, "ls (/usr/local)")
+	vt.print('$')
+
+	// The word continues on the wrapped row
+	vt.SelectWord(5, 0)
+	assert.Equal(t, "/usr/local", vt.Copy())
+	vt.SelectWord(1, 1)
+	assert.Equal(t, "/usr/local", vt.Copy())
+
+	vt.SelectWord(0, 0)
+	assert.Equal(t, "ls", vt.Copy())
+	vt.SelectWord(3, 0)
+	assert.Equal(t, "(", vt.Copy())
+}
+
+func TestSelectLine(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	printLine(vt, "first")
+	printLine(vt, "second")
+	vt.print('$')
+
+	// Scrolled to the first line, which wraps over two rows
+	vt.ScrollUp(vt.primaryScrollback.len())
+	vt.SelectLine(1)
+	assert.Equal(t, "first", vt.Copy())
+}
+
+func TestCopy(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	printLine(vt, "one")
+	printLine(vt, "twothree")
+	vt.print('$')
+
+	// The selection spans the scrollback and the screen, which are not all visible
+	vt.ScrollUp(vt.primaryScrollback.len())
+	vt.SelectStart(1, 0)
+	vt.ScrollReset()
+	vt.SelectEnd(0, 1)
+	assert.Equal(t, "ne\ntwothree\n$", vt.Copy())
+
+	vt.ClearSelection()
+	assert.Equal(t, "", vt.Copy())
+}
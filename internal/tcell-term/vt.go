@@ -6,10 +6,10 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime/debug"
 	"strings"
 	"sync"
-	"unicode"
 
 	"github.com/creack/pty"
 	"github.com/gdamore/tcell/v2"
@@ -31,15 +31,19 @@ type VT struct {
 	// Set the TERM environment variable to be passed to the command's
 	// environment. If not set, xterm-256color will be used
 	TERM string
+	// If set, receives a copy of everything the command writes to the
+	// terminal. Write errors are ignored and do not stop the terminal
+	Output io.Writer
 
 	mu sync.Mutex
 
 	activeScreen      [][]cell
 	altScreen         [][]cell
 	primaryScreen     [][]cell
-	primaryScrollback [][]cell
+	primaryScrollback *scrollback
 
 	scroll int
+	mark   int // first scrollback line written since the terminal was last cleared
 
 	charsets charsets
 	cursor   cursor
@@ -57,20 +61,23 @@ type VT struct {
 	eventHandler func(tcell.Event)
 	parser       *Parser
 	pty          *os.File
+	input        *inputQueue // input written to the pty
 	surface      Surface
 	events       chan tcell.Event
 
 	mouseBtn tcell.ButtonMask
 
 	selection *selection
+	search    *search
+	copy      *copyMode
 }
 
 type selection struct {
-	content strings.Builder
-	startX  int
-	startY  int
-	endX    int
-	endY    int
+	startX int
+	startY int
+	endX   int
+	endY   int
+	block  bool // the rectangle between the start and the end
 }
 
 type cursorState struct {
@@ -96,6 +103,8 @@ func New() *VT {
 		Logger: log.New(io.Discard, "", log.Flags()),
 		OSC8:   true,
 		scroll: -1,
+
+		primaryScrollback: newScrollback(),
 		selection: &selection{
 			startX: 0,
 			startY: 0,
@@ -164,13 +173,36 @@ func (vt *VT) Start(cmd *exec.Cmd) error {
 		Cols: uint16(w),
 		Rows: uint16(h),
 	}
-	vt.pty, err = pty.StartWithAttrs(cmd, &winsize, getPtyAttr())
+	tty, err := pty.StartWithAttrs(cmd, &winsize, getPtyAttr())
 	if err != nil {
 		return err
 	}
+	vt.mu.Lock()
+	if vt.input != nil {
+		vt.input.close()
+	}
+	vt.pty = tty
+	vt.input = newInputQueue(tty)
+	input := vt.input
+	vt.mu.Unlock()
+
+	// Reap the process as soon as it exits, so its state is available when
+	// the terminal reports it was closed
+	exited := make(chan struct{})
+	go func() {
+		_ = process.Wait(cmd)
+		close(exited)
+	}()
 
 	vt.Resize(w, h)
-	vt.parser = NewParser(vt.pty)
+	// Keep a reference to the parser, the goroutine must not read the output
+	// of a command started later on the same terminal
+	var r io.Reader = tty
+	if vt.Output != nil {
+		r = &teeReader{r: tty, w: vt.Output}
+	}
+	parser := NewParser(r)
+	vt.parser = parser
 	go func() {
 		defer vt.recover()
 		for {
@@ -178,11 +210,14 @@ func (vt *VT) Start(cmd *exec.Cmd) error {
 			case ev := <-vt.events:
 				vt.eventHandler(ev)
 			default:
-				seq := vt.parser.Next()
+				seq := parser.Next()
 				switch seq := seq.(type) {
 				case EOF:
+					input.close()
+					<-exited
 					vt.eventHandler(&EventClosed{
 						EventTerminal: newEventTerminal(vt),
+						cmd:           cmd,
 					})
 					return
 				default:
@@ -194,6 +229,20 @@ func (vt *VT) Start(cmd *exec.Cmd) error {
 	return nil
 }
 
+// teeReader copies everything read from r to w, ignoring write errors
+type teeReader struct {
+	r io.Reader
+	w io.Writer
+}
+
+func (t *teeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		_, _ = t.w.Write(p[:n])
+	}
+	return n, err
+}
+
 func (vt *VT) update(seq Sequence) {
 	vt.mu.Lock()
 	defer vt.mu.Unlock()
@@ -241,6 +290,37 @@ func (vt *VT) String() string {
 	return str.String()
 }
 
+// Match reports whether any line written since the terminal was last cleared
+// matches the regular expression, on the primary screen or in the primary
+// scrollback
+func (vt *VT) Match(re *regexp.Regexp) bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	for i := vt.mark; i < vt.primaryScrollback.len(); i += 1 {
+		if re.MatchString(lineString(vt.primaryScrollback.line(i))) {
+			return true
+		}
+	}
+	for _, line := range vt.primaryScreen {
+		if re.MatchString(lineString(line)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineString returns the text of a line without trailing blanks
+func lineString(line []cell) string {
+	str := strings.Builder{}
+	for col := range line {
+		_, _ = str.WriteRune(line[col].rune())
+		for _, comb := range line[col].combining {
+			_, _ = str.WriteRune(comb)
+		}
+	}
+	return strings.TrimRight(str.String(), " ")
+}
+
 func (vt *VT) recover() {
 	err := recover()
 	if err == nil {
@@ -268,59 +348,26 @@ func (vt *VT) Cursor() (int, int, tcell.CursorStyle, bool) {
 }
 
 func (vt *VT) Resize(w int, h int) {
-	primary := vt.primaryScreen
-	vt.altScreen = make([][]cell, h)
-	vt.primaryScreen = make([][]cell, h)
-	for i := range vt.altScreen {
-		vt.altScreen[i] = make([]cell, w)
-		vt.primaryScreen[i] = make([]cell, w)
-	}
-	last := vt.cursor.row
+	// The output goroutine updates the screens while the UI resizes them
+	vt.mu.Lock()
+	vt.reflow(w, h)
+	vt.margin.top = 0
 	vt.margin.bottom = row(h) - 1
+	vt.margin.left = 0
 	vt.margin.right = column(w) - 1
-	vt.cursor.row = 0
-	vt.cursor.col = 0
-	vt.lastCol = false
-	vt.activeScreen = vt.primaryScreen
-
-	// transfer primary to new, skipping the last row
-	for row := 0; row < len(primary); row += 1 {
-		if row == int(last) {
-			break
-		}
-		wrapped := false
-		for col := 0; col < len(primary[0]); col += 1 {
-			cell := primary[row][col]
-			vt.cursor.attrs = cell.attrs
-			vt.print(cell.content)
-			wrapped = cell.wrapped
-		}
-		if !wrapped {
-			vt.nel()
-		}
-	}
+	vt.cursor.row = min(vt.cursor.row, vt.margin.bottom)
+	vt.cursor.col = min(vt.cursor.col, vt.margin.right)
 	switch vt.mode & smcup {
 	case 0:
 		vt.activeScreen = vt.primaryScreen
 	default:
 		vt.activeScreen = vt.altScreen
 	}
-	/*
-		nextScrollback := [][]cell{}
-		currentRow := []cell{}
-		for _, row := range vt.primaryScrollback {
-			for col, c := range row {
-				if col >= w {
-					nextScrollback = append(nextScrollback, currentRow)
-					currentRow = []cell{}
-				}
-				currentRow = append(currentRow, c)
-			}
-		}
-		vt.primaryScrollback = nextScrollback
-	*/
+	vt.clampCopy()
+	tty := vt.pty
+	vt.mu.Unlock()
 
-	_ = pty.Setsize(vt.pty, &pty.Winsize{
+	_ = pty.Setsize(tty, &pty.Winsize{
 		Cols: uint16(w),
 		Rows: uint16(h),
 	})
@@ -414,9 +461,7 @@ func (vt *VT) print(r rune) {
 // usually scroll up would mean you shift rows down
 func (vt *VT) scrollUp(n int) {
 	for i := 0; i < n; i += 1 {
-		history := make([]cell, len(vt.activeScreen[i]))
-		copy(history, vt.activeScreen[i])
-		vt.primaryScrollback = append(vt.primaryScrollback, history)
+		vt.dropLines(vt.primaryScrollback.push(vt.activeScreen[i]))
 	}
 	for row := range vt.activeScreen {
 		if row > int(vt.margin.bottom) {
@@ -448,13 +493,33 @@ func (vt *VT) scrollDown(n int) {
 	}
 }
 
+// Stop stops the command in the background and closes the terminal once the
+// command exited, the EventClosed of the command reports when it is gone
+func (vt *VT) Stop() {
+	vt.mu.Lock()
+	cmd, pty, input := vt.cmd, vt.pty, vt.input
+	vt.mu.Unlock()
+	go func() {
+		process.Kill(cmd)
+		if pty != nil {
+			pty.Close()
+		}
+		if input != nil {
+			input.close()
+		}
+	}()
+}
+
 func (vt *VT) Close() {
 	if vt.cmd != nil && vt.cmd.Process != nil {
-		process.Kill(vt.cmd.Process)
+		process.Kill(vt.cmd)
 	}
 	vt.mu.Lock()
 	defer vt.mu.Unlock()
 	vt.pty.Close()
+	if vt.input != nil {
+		vt.input.close()
+	}
 }
 
 func (vt *VT) Attach(fn func(ev tcell.Event)) {
@@ -480,35 +545,53 @@ func (vt *VT) SetSurface(srf Surface) {
 }
 
 func (vt *VT) ScrollUp(offset int) {
-	if !vt.IsScrolling() {
-		if len(vt.primaryScrollback) == 0 {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if !vt.isScrolling() {
+		if vt.primaryScrollback.len() == 0 {
 			return
 		}
-		vt.scroll = len(vt.primaryScrollback)
+		vt.scroll = vt.primaryScrollback.len()
 	}
 	vt.scroll = vt.scroll - offset
 	vt.scroll = max(0, vt.scroll)
 }
 
 func (vt *VT) ScrollDown(offset int) {
-	if !vt.IsScrolling() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if !vt.isScrolling() {
 		return
 	}
 	vt.scroll = vt.scroll + offset
-	if vt.scroll >= len(vt.primaryScrollback) {
-		vt.ScrollReset()
+	if vt.scroll >= vt.primaryScrollback.len() {
+		vt.resetScroll()
 	}
 }
 
 func (vt *VT) ScrollReset() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.resetScroll()
+}
+
+func (vt *VT) resetScroll() {
 	vt.scroll = -1
 }
 
 func (vt *VT) Scrollable() bool {
-	return len(vt.primaryScrollback) > 0
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.primaryScrollback.len() > 0
 }
 
 func (vt *VT) IsScrolling() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.isScrolling()
+}
+
+func (vt *VT) isScrolling() bool {
 	return vt.scroll != -1
 }
 
@@ -520,13 +603,12 @@ func (vt *VT) Draw() {
 		return
 	}
 	offset := 0
-	vt.selection.content = strings.Builder{}
-	if vt.IsScrolling() {
-		for x := vt.scroll; x < len(vt.primaryScrollback); x += 1 {
+	if vt.isScrolling() {
+		for x := vt.scroll; x < vt.primaryScrollback.len(); x += 1 {
 			if offset >= vt.height() {
 				break
 			}
-			vt.drawRow(offset, vt.primaryScrollback[x])
+			vt.drawRow(offset, vt.primaryScrollback.line(x))
 			offset += 1
 		}
 	}
@@ -540,11 +622,14 @@ func (vt *VT) Draw() {
 }
 
 func (vt *VT) drawRow(row int, cols []cell) {
-	scrollOffset := len(vt.primaryScrollback)
+	scrollOffset := vt.primaryScrollback.len()
 	if vt.scroll != -1 {
 		scrollOffset = vt.scroll
 	}
-	builder := strings.Builder{}
+	var matches [][2]int
+	if vt.search != nil {
+		matches = vt.search.matches(cols)
+	}
 	for col := 0; col < len(cols); {
 		cell := cols[col]
 		w := cell.width
@@ -554,9 +639,11 @@ func (vt *VT) drawRow(row int, cols []cell) {
 			w = 1
 		}
 		style := cell.attrs
-		if vt.selection != nil && isCellSelected(col, row+scrollOffset, vt.selection.startX, vt.selection.startY, vt.selection.endX, vt.selection.endY) {
+		if vt.search != nil {
+			style = vt.search.style(row+scrollOffset, col, matches, style)
+		}
+		if vt.selection != nil && vt.selection.contains(col, row+scrollOffset) {
 			style = style.Reverse(true)
-			builder.WriteRune(content)
 		}
 		vt.surface.SetContent(col, row, content, cell.combining, style)
 		if w == 0 {
@@ -564,10 +651,6 @@ func (vt *VT) drawRow(row int, cols []cell) {
 		}
 		col += 1
 	}
-	if builder.Len() > 0 {
-		vt.selection.content.WriteString(strings.TrimRight(builder.String(), " "))
-		vt.selection.content.WriteRune('\n')
-	}
 }
 
 func (vt *VT) HandleEvent(e tcell.Event) bool {
@@ -575,36 +658,111 @@ func (vt *VT) HandleEvent(e tcell.Event) bool {
 	defer vt.mu.Unlock()
 	switch e := e.(type) {
 	case *tcell.EventKey:
-		vt.pty.WriteString(keyCode(e))
+		vt.send(keyCode(e))
 		return true
 	case *tcell.EventPaste:
 		switch {
 		case vt.mode&paste == 0:
 			return false
 		case e.Start():
-			vt.pty.WriteString(info.PasteStart)
+			vt.send(info.PasteStart)
 			return true
 		case e.End():
-			vt.pty.WriteString(info.PasteEnd)
+			vt.send(info.PasteEnd)
 			return true
 		}
 	case *tcell.EventMouse:
 		str := vt.handleMouse(e)
-		vt.pty.WriteString(str)
+		vt.send(str)
 	}
 	return false
 }
 
-func (vt *VT) Clear() {
-	vt.ris()
+// Write queues raw input for the running command
+func (vt *VT) Write(p []byte) (int, error) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if vt.input == nil {
+		return 0, fmt.Errorf("terminal is not started")
+	}
+	vt.input.push(p)
+	return len(p), nil
 }
 
-func (vt *VT) Copy() string {
-	return strings.TrimRightFunc(vt.selection.content.String(), unicode.IsSpace)
+// Queues input for the running command, must be called with the lock held
+func (vt *VT) send(s string) {
+	if vt.input != nil {
+		vt.input.push([]byte(s))
+	}
+}
+
+// Scrollback returns the text of the primary scrollback, one line per row
+func (vt *VT) Scrollback() string {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	lines := make([]string, 0, vt.primaryScrollback.len())
+	for i := range vt.primaryScrollback.len() {
+		lines = append(lines, lineString(vt.primaryScrollback.line(i)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetScrollback limits the number of scrollback lines kept in memory, zero
+// uses DefaultScrollback. If spill is positive, up to spill older lines are
+// compressed to temporary files instead of being dropped.
+func (vt *VT) SetScrollback(lines int, spill int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.dropLines(vt.primaryScrollback.setLimits(lines, spill))
+}
+
+// ClearScrollback removes the primary scrollback, including the lines
+// spilled to disk
+func (vt *VT) ClearScrollback() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	n := vt.primaryScrollback.len()
+	vt.primaryScrollback.clear()
+	vt.dropLines(n)
+}
+
+// Keeps the scroll position, the selection and the search on the same lines
+// after n lines were dropped from the start of the scrollback
+func (vt *VT) dropLines(n int) {
+	if n == 0 {
+		return
+	}
+	if vt.scroll != -1 {
+		vt.scroll = max(vt.scroll-n, 0)
+	}
+	vt.mark = max(vt.mark-n, 0)
+	if vt.selection.startY -= n; vt.selection.startY < 0 {
+		vt.selection.startX, vt.selection.startY = 0, 0
+	}
+	if vt.selection.endY != -1 {
+		vt.selection.endY = max(vt.selection.endY-n, 0)
+	}
+	if vt.search != nil {
+		vt.search.drop(n)
+	}
+	if vt.copy != nil {
+		vt.copy.line = max(vt.copy.line-n, 0)
+		vt.copy.anchorLine = max(vt.copy.anchorLine-n, 0)
+	}
+}
+
+// Clear resets the terminal for the next command, the scrollback is kept
+func (vt *VT) Clear() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.ris()
+	vt.mark = vt.primaryScrollback.len()
 }
 
 func (vt *VT) SelectStart(x int, y int) {
-	scrollOffset := len(vt.primaryScrollback)
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	scrollOffset := vt.primaryScrollback.len()
 	if vt.scroll != -1 {
 		scrollOffset = vt.scroll
 	}
@@ -618,7 +776,9 @@ func (vt *VT) SelectStart(x int, y int) {
 }
 
 func (vt *VT) SelectEnd(x int, y int) {
-	scrollOffset := len(vt.primaryScrollback)
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	scrollOffset := vt.primaryScrollback.len()
 	if vt.scroll != -1 {
 		scrollOffset = vt.scroll
 	}
@@ -628,14 +788,37 @@ func (vt *VT) SelectEnd(x int, y int) {
 }
 
 func (vt *VT) HasSelection() bool {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return vt.hasSelection()
+}
+
+func (vt *VT) hasSelection() bool {
 	return vt.selection.endX != -1 && vt.selection.endY != -1
 }
 
 func (vt *VT) ClearSelection() {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.clearSelection()
+}
+
+func (vt *VT) clearSelection() {
 	vt.selection.startX = 0
 	vt.selection.startY = 0
 	vt.selection.endX = -1
 	vt.selection.endY = -1
+	vt.selection.block = false
+}
+
+// Returns true if the cell is selected
+func (s *selection) contains(x int, y int) bool {
+	if s.block {
+		return s.endX >= 0 && s.endY >= 0 &&
+			y >= min(s.startY, s.endY) && y <= max(s.startY, s.endY) &&
+			x >= min(s.startX, s.endX) && x <= max(s.startX, s.endX)
+	}
+	return isCellSelected(x, y, s.startX, s.startY, s.endX, s.endY)
 }
 
 func isCellSelected(x, y, startX, startY, endX, endY int) bool {
@@ -1,11 +1,31 @@
 package tcellterm
 
 import (
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
 
+// testSurface is a surface of a fixed size that discards the content
+type testSurface struct {
+	mu   sync.Mutex
+	w, h int
+}
+
+func (s *testSurface) SetContent(x int, y int, ch rune, comb []rune, style tcell.Style) {}
+
+func (s *testSurface) Size() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w, s.h
+}
+
 func TestResize(t *testing.T) {
 	vt := New()
 	w := 4
@@ -15,6 +35,39 @@ func TestResize(t *testing.T) {
 	assert.Equal(t, w, len(vt.activeScreen[0]))
 }
 
+func TestResize_Output(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no pty on windows")
+	}
+
+	vt := New()
+	vt.SetSurface(&testSurface{w: 20, h: 5})
+	vt.SetScrollback(50, 0)
+	closed := make(chan struct{})
+	vt.Attach(func(ev tcell.Event) {
+		if _, ok := ev.(*EventClosed); ok {
+			close(closed)
+		}
+	})
+	cmd := exec.Command("/bin/sh", "-c", "i=0; while [ $i -lt 20000 ]; do echo line $i; i=$((i+1)); done")
+	assert.NoError(t, vt.Start(cmd))
+	defer vt.Close()
+
+	// The screens are reflowed while the output updates them
+	timeout := time.After(30 * time.Second)
+	for i := 0; ; i++ {
+		select {
+		case <-closed:
+			return
+		case <-timeout:
+			t.Fatal("the command did not exit")
+		default:
+			vt.Resize(10+i%20, 2+i%7)
+			runtime.Gosched()
+		}
+	}
+}
+
 func TestString(t *testing.T) {
 	vt := New()
 	w := 2
@@ -27,6 +80,49 @@ func TestString(t *testing.T) {
 	assert.Equal(t, "vt", vt.String())
 }
 
+func TestMatch(t *testing.T) {
+	vt := New()
+	vt.Resize(8, 1)
+	vt.primaryScrollback.push([]cell{{content: 'o'}, {content: 'l'}, {content: 'd'}})
+	for _, r := range "ready" {
+		vt.print(r)
+	}
+
+	assert.True(t, vt.Match(regexp.MustCompile(`^ready$`)))
+	assert.True(t, vt.Match(regexp.MustCompile(`old`)))
+	assert.False(t, vt.Match(regexp.MustCompile(`listening`)))
+
+	// Only lines written since the terminal was cleared match
+	vt.Clear()
+	assert.False(t, vt.Match(regexp.MustCompile(`old`)))
+}
+
+func TestMatch_Reflow(t *testing.T) {
+	vt := New()
+	vt.Resize(4, 2)
+	vt.SetScrollback(5, 0)
+	printLine(vt, "old line")
+	printLine(vt, "x")
+	vt.Clear()
+
+	for _, text := range []string{"read", "a", "b", "c"} {
+		printLine(vt, text)
+	}
+	assert.True(t, vt.Match(regexp.MustCompile(`^read$`)))
+
+	// Joining the wrapped rows of older lines moves the new ones up
+	vt.Resize(8, 2)
+	assert.True(t, vt.Match(regexp.MustCompile(`^read$`)))
+	assert.False(t, vt.Match(regexp.MustCompile(`old`)))
+
+	// Old lines are evicted from the full scrollback
+	for _, text := range []string{"d", "e"} {
+		printLine(vt, text)
+	}
+	assert.True(t, vt.Match(regexp.MustCompile(`^read$`)))
+	assert.False(t, vt.Match(regexp.MustCompile(`old`)))
+}
+
 func TestPrint(t *testing.T) {
 	t.Run("No modes", func(t *testing.T) {
 		vt := New()